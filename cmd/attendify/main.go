@@ -16,6 +16,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/tahiriqbal095/attendify/internal/config"
 	"github.com/tahiriqbal095/attendify/internal/db"
+	"github.com/tahiriqbal095/attendify/internal/jwtkeys"
 	"github.com/tahiriqbal095/attendify/internal/logger"
 	"github.com/tahiriqbal095/attendify/internal/server"
 )
@@ -50,8 +51,22 @@ func main() {
 	}
 	defer db.Close(pool)
 
-	// Create and start HTTP server.
-	srv := server.NewServer(cfg.AppPort, log, pool)
+	// Load the keys that sign and verify access tokens.
+	keys, err := loadSigningKeys(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load JWT signing keys")
+	}
+
+	// Create the HTTP server and make sure an admin can sign in.
+	srv, err := server.NewServer(cfg, log, pool, keys)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create server")
+	}
+	if err := seedAdmin(srv); err != nil {
+		log.Fatal().Err(err).Msg("Failed to seed admin account")
+	}
+
+	// Start the HTTP server.
 	go func() {
 		if err := srv.Start(); err != nil {
 			log.Fatal().Err(err).Msg("Server failed to start")
@@ -79,6 +94,26 @@ func connectDatabase(databaseURL string, log zerolog.Logger) (*db.Pool, error) {
 	return db.NewPool(ctx, databaseURL, log)
 }
 
+// loadSigningKeys returns the asymmetric key set when a signing key file is
+// configured, falling back to HS256 with JWT_SECRET otherwise.
+func loadSigningKeys(cfg *config.Config) (*jwtkeys.KeySet, error) {
+	if cfg.JWTSigningKeyFile != "" {
+		return jwtkeys.LoadKeySet(cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles)
+	}
+	if cfg.JWTSecret == "" {
+		return nil, fmt.Errorf("either JWT_SIGNING_KEY_FILE or JWT_SECRET must be set")
+	}
+	return jwtkeys.NewHMACKeySet([]byte(cfg.JWTSecret)), nil
+}
+
+// seedAdmin creates the first admin from ADMIN_EMAIL and ADMIN_PASSWORD if needed.
+func seedAdmin(srv *server.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbConnectTimeout)
+	defer cancel()
+
+	return srv.SeedAdmin(ctx)
+}
+
 // waitForShutdownSignal blocks until SIGINT or SIGTERM is received.
 // SIGINT is triggered by Ctrl+C, SIGTERM by `kill` or container orchestrators.
 func waitForShutdownSignal() os.Signal {
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,21 +14,204 @@ type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	Environment string
+
+	// TrustedProxies are the addresses or CIDRs of reverse proxies whose
+	// X-Forwarded-For header is believed. Empty trusts none, so the client
+	// IP is always the connection's address.
+	TrustedProxies []string
+
+	// JWTSigningKeyFile is a PEM Ed25519 or RSA private key. When set, tokens
+	// are signed with it instead of JWTSecret and its public key is published.
+	JWTSigningKeyFile string
+	// JWTVerificationKeyFiles are previous keys still accepted during rotation.
+	JWTVerificationKeyFiles []string
+
+	// AccessTokenTTL is how long a JWT access token is valid.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token can be exchanged for a new pair.
+	RefreshTokenTTL time.Duration
+
+	// FrontendURL is the web app base used to build links in emails.
+	FrontendURL string
+	// PasswordResetTTL is how long an emailed password reset link works.
+	PasswordResetTTL time.Duration
+	// EmailVerificationTTL is how long an emailed verification link works.
+	EmailVerificationTTL time.Duration
+	// InviteTTL is how long class invitations and the set-password links
+	// emailed to students added by roster import work.
+	InviteTTL time.Duration
+	// EmailVerificationPolicy is what unverified accounts are barred from:
+	// "none", "enrollment" or "login".
+	EmailVerificationPolicy string
+
+	// LoginLockoutThreshold is how many failed sign-ins lock an account.
+	LoginLockoutThreshold int
+	// LoginLockoutDuration is how long a locked account stays locked.
+	LoginLockoutDuration time.Duration
+	// LoginIPThreshold is how many failed sign-ins a client IP gets before backoff.
+	LoginIPThreshold int
+
+	// MFARequiredRoles must use two-factor authentication; other roles may opt in.
+	MFARequiredRoles []string
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+	// MFAChallengeTTL is how long a user has to enter their code after the password.
+	MFAChallengeTTL time.Duration
+
+	// AdminEmail, AdminName and AdminPassword seed the first admin account
+	// at startup when no active admin exists. Leave AdminEmail empty to skip.
+	AdminEmail    string
+	AdminName     string
+	AdminPassword string
+
+	// MailDriver selects how email is sent: "smtp", or "log" for development.
+	MailDriver string
+	// MailDir, with the log driver, is where messages are also written as files.
+	MailDir      string
+	MailFrom     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// SessionDuration is how long an attendance session stays open
+	// when the teacher does not close it or pick a duration.
+	SessionDuration time.Duration
+	// LateThreshold is how long after a session opens marks still count as present.
+	LateThreshold time.Duration
+
+	// CheckinCodePeriod is how often a session's check-in code rotates.
+	CheckinCodePeriod time.Duration
+	// CheckinCodeSkew is how many previous codes are still accepted.
+	CheckinCodeSkew int
+	// CheckinURL is the deep-link base encoded into session QR codes.
+	CheckinURL string
 }
 
 func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("FRONTEND_URL", "http://localhost:3000")
+	viper.SetDefault("PASSWORD_RESET_TTL", "1h")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "24h")
+	viper.SetDefault("INVITE_TTL", "168h")
+	viper.SetDefault("EMAIL_VERIFICATION_POLICY", "enrollment")
+	viper.SetDefault("LOGIN_LOCKOUT_THRESHOLD", 10)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "30m")
+	viper.SetDefault("LOGIN_IP_THRESHOLD", 50)
+	viper.SetDefault("MFA_REQUIRED_ROLES", "")
+	viper.SetDefault("MFA_ISSUER", "Attendify")
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("ADMIN_NAME", "Administrator")
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "Attendify <no-reply@attendify.local>")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("SESSION_DURATION", "90m")
+	viper.SetDefault("LATE_THRESHOLD", "10m")
+	viper.SetDefault("CHECKIN_CODE_PERIOD", "15s")
+	viper.SetDefault("CHECKIN_CODE_SKEW", 1)
+	viper.SetDefault("CHECKIN_URL", "attendify://checkin")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Error reading config file: %v", err)
 		return nil, err
 	}
 
-	return &Config{
+	cfg := &Config{
 		AppPort:     viper.GetString("APP_PORT"),
 		DatabaseURL: viper.GetString("DATABASE_URL"),
 		JWTSecret:   viper.GetString("JWT_SECRET"),
 		Environment: viper.GetString("ENVIRONMENT"),
-	}, nil
+
+		TrustedProxies: splitList(viper.GetString("TRUSTED_PROXIES")),
+
+		JWTSigningKeyFile:       viper.GetString("JWT_SIGNING_KEY_FILE"),
+		JWTVerificationKeyFiles: splitList(viper.GetString("JWT_VERIFICATION_KEY_FILES")),
+
+		AccessTokenTTL:  viper.GetDuration("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL: viper.GetDuration("REFRESH_TOKEN_TTL"),
+
+		FrontendURL:      strings.TrimRight(viper.GetString("FRONTEND_URL"), "/"),
+		PasswordResetTTL: viper.GetDuration("PASSWORD_RESET_TTL"),
+
+		EmailVerificationTTL:    viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		EmailVerificationPolicy: viper.GetString("EMAIL_VERIFICATION_POLICY"),
+		InviteTTL:               viper.GetDuration("INVITE_TTL"),
+
+		LoginLockoutThreshold: viper.GetInt("LOGIN_LOCKOUT_THRESHOLD"),
+		LoginLockoutDuration:  viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+		LoginIPThreshold:      viper.GetInt("LOGIN_IP_THRESHOLD"),
+
+		MFARequiredRoles: splitList(viper.GetString("MFA_REQUIRED_ROLES")),
+		MFAIssuer:        viper.GetString("MFA_ISSUER"),
+		MFAChallengeTTL:  viper.GetDuration("MFA_CHALLENGE_TTL"),
+
+		AdminEmail:    viper.GetString("ADMIN_EMAIL"),
+		AdminName:     viper.GetString("ADMIN_NAME"),
+		AdminPassword: viper.GetString("ADMIN_PASSWORD"),
+
+		MailDriver:   viper.GetString("MAIL_DRIVER"),
+		MailDir:      viper.GetString("MAIL_DIR"),
+		MailFrom:     viper.GetString("MAIL_FROM"),
+		SMTPHost:     viper.GetString("SMTP_HOST"),
+		SMTPPort:     viper.GetInt("SMTP_PORT"),
+		SMTPUsername: viper.GetString("SMTP_USERNAME"),
+		SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+
+		SessionDuration:   viper.GetDuration("SESSION_DURATION"),
+		LateThreshold:     viper.GetDuration("LATE_THRESHOLD"),
+		CheckinCodePeriod: viper.GetDuration("CHECKIN_CODE_PERIOD"),
+		CheckinCodeSkew:   viper.GetInt("CHECKIN_CODE_SKEW"),
+		CheckinURL:        viper.GetString("CHECKIN_URL"),
+	}
+
+	switch cfg.EmailVerificationPolicy {
+	case "none", "enrollment", "login":
+	default:
+		return nil, fmt.Errorf("EMAIL_VERIFICATION_POLICY must be none, enrollment or login, got %q",
+			cfg.EmailVerificationPolicy)
+	}
+
+	if cfg.InviteTTL <= 0 {
+		return nil, fmt.Errorf("INVITE_TTL must be positive, got %s", cfg.InviteTTL)
+	}
+
+	if cfg.LoginLockoutThreshold < 1 || cfg.LoginIPThreshold < 1 {
+		return nil, fmt.Errorf("LOGIN_LOCKOUT_THRESHOLD and LOGIN_IP_THRESHOLD must be at least 1")
+	}
+
+	if cfg.AdminEmail != "" && (len(cfg.AdminPassword) < 8 || len(cfg.AdminPassword) > 72) {
+		return nil, fmt.Errorf("ADMIN_PASSWORD must be 8 to 72 characters when ADMIN_EMAIL is set")
+	}
+
+	for _, role := range cfg.MFARequiredRoles {
+		switch role {
+		case "teacher", "student", "admin":
+		default:
+			return nil, fmt.Errorf("MFA_REQUIRED_ROLES must list teacher, student or admin, got %q", role)
+		}
+	}
+
+	// Codes are counted in whole seconds since the epoch.
+	if cfg.CheckinCodePeriod < time.Second || cfg.CheckinCodePeriod%time.Second != 0 {
+		return nil, fmt.Errorf("CHECKIN_CODE_PERIOD must be a whole number of seconds, got %s", cfg.CheckinCodePeriod)
+	}
+	if cfg.CheckinCodeSkew < 0 {
+		return nil, fmt.Errorf("CHECKIN_CODE_SKEW must not be negative, got %d", cfg.CheckinCodeSkew)
+	}
+
+	return cfg, nil
+}
+
+// splitList parses a comma-separated setting, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,63 @@
+-- migrate:up
+CREATE TABLE class_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    opened_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP,
+    CHECK (expires_at > opened_at)
+);
+
+CREATE INDEX idx_class_sessions_class_id ON class_sessions(class_id);
+
+-- At most one session per class may be open at a time.
+CREATE UNIQUE INDEX idx_class_sessions_open ON class_sessions(class_id) WHERE closed_at IS NULL;
+
+-- Backfill one closed session per existing (class, date) pair.
+INSERT INTO class_sessions (class_id, opened_by, opened_at, expires_at, closed_at)
+SELECT d.class_id, c.teacher_id, d.session_date, d.session_date + INTERVAL '1 day', d.session_date + INTERVAL '1 day'
+FROM (SELECT DISTINCT class_id, session_date FROM attendance) d
+JOIN classes c ON c.id = d.class_id;
+
+ALTER TABLE attendance ADD COLUMN session_id UUID REFERENCES class_sessions(id) ON DELETE CASCADE;
+
+UPDATE attendance a
+SET session_id = s.id
+FROM class_sessions s
+WHERE s.class_id = a.class_id AND s.opened_at = a.session_date;
+
+ALTER TABLE attendance ALTER COLUMN session_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_attendance_session;
+ALTER TABLE attendance DROP CONSTRAINT attendance_class_id_student_id_session_date_key;
+ALTER TABLE attendance DROP COLUMN session_date;
+ALTER TABLE attendance ADD CONSTRAINT attendance_session_id_student_id_key UNIQUE (session_id, student_id);
+
+CREATE INDEX idx_attendance_session_id ON attendance(session_id);
+
+-- migrate:down
+ALTER TABLE attendance ADD COLUMN session_date DATE;
+
+UPDATE attendance a
+SET session_date = s.opened_at::date
+FROM class_sessions s
+WHERE s.id = a.session_id;
+
+-- Several sessions on one day collapse into a single date; keep the earliest mark.
+DELETE FROM attendance a
+USING attendance b
+WHERE a.class_id = b.class_id
+  AND a.student_id = b.student_id
+  AND a.session_date = b.session_date
+  AND (a.marked_at, a.id) > (b.marked_at, b.id);
+
+ALTER TABLE attendance ALTER COLUMN session_date SET NOT NULL;
+ALTER TABLE attendance DROP CONSTRAINT attendance_session_id_student_id_key;
+DROP INDEX IF EXISTS idx_attendance_session_id;
+ALTER TABLE attendance DROP COLUMN session_id;
+ALTER TABLE attendance ADD CONSTRAINT attendance_class_id_student_id_session_date_key UNIQUE (class_id, student_id, session_date);
+
+CREATE INDEX idx_attendance_session ON attendance(class_id, session_date);
+
+DROP TABLE IF EXISTS class_sessions;
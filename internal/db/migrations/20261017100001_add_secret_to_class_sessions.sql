@@ -0,0 +1,11 @@
+-- migrate:up
+ALTER TABLE class_sessions ADD COLUMN secret BYTEA;
+
+-- Sessions created before rotating codes existed are all closed,
+-- so any random secret is fine for them.
+UPDATE class_sessions SET secret = decode(md5(random()::text || id::text), 'hex') WHERE secret IS NULL;
+
+ALTER TABLE class_sessions ALTER COLUMN secret SET NOT NULL;
+
+-- migrate:down
+ALTER TABLE class_sessions DROP COLUMN IF EXISTS secret;
@@ -0,0 +1,47 @@
+-- migrate:up
+ALTER TABLE classes
+    ADD COLUMN geofence_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN geofence_radius_m INTEGER CHECK (geofence_radius_m > 0),
+    ADD CONSTRAINT classes_geofence_complete CHECK (
+        NOT geofence_enabled
+        OR (latitude IS NOT NULL AND longitude IS NOT NULL AND geofence_radius_m IS NOT NULL)
+    );
+
+ALTER TABLE attendance
+    ADD COLUMN latitude DOUBLE PRECISION,
+    ADD COLUMN longitude DOUBLE PRECISION,
+    ADD COLUMN accuracy_m DOUBLE PRECISION,
+    ADD COLUMN distance_m DOUBLE PRECISION;
+
+CREATE TABLE geofence_violations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL REFERENCES class_sessions(id) ON DELETE CASCADE,
+    student_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    accuracy_m DOUBLE PRECISION,
+    distance_m DOUBLE PRECISION NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_geofence_violations_class_id ON geofence_violations(class_id, attempted_at DESC);
+CREATE INDEX idx_geofence_violations_student_id ON geofence_violations(student_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS geofence_violations;
+
+ALTER TABLE attendance
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS accuracy_m,
+    DROP COLUMN IF EXISTS distance_m;
+
+ALTER TABLE classes
+    DROP CONSTRAINT IF EXISTS classes_geofence_complete,
+    DROP COLUMN IF EXISTS geofence_enabled,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS geofence_radius_m;
@@ -0,0 +1,19 @@
+-- migrate:up
+ALTER TABLE attendance
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'present'
+        CHECK (status IN ('present', 'late', 'absent', 'excused')),
+    ADD COLUMN reason VARCHAR(500);
+
+CREATE INDEX idx_attendance_session_status ON attendance(session_id, status);
+
+-- Marks after late_at count as late; NULL means the session never turns late.
+ALTER TABLE class_sessions ADD COLUMN late_at TIMESTAMP;
+
+-- migrate:down
+ALTER TABLE class_sessions DROP COLUMN IF EXISTS late_at;
+
+DROP INDEX IF EXISTS idx_attendance_session_status;
+
+ALTER TABLE attendance
+    DROP COLUMN IF EXISTS reason,
+    DROP COLUMN IF EXISTS status;
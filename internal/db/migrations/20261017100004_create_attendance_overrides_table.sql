@@ -0,0 +1,23 @@
+-- migrate:up
+ALTER TABLE attendance
+    ADD COLUMN overridden_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN overridden_at TIMESTAMP;
+
+CREATE TABLE attendance_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    attendance_id UUID NOT NULL REFERENCES attendance(id) ON DELETE CASCADE,
+    previous_status VARCHAR(20),
+    new_status VARCHAR(20) NOT NULL,
+    reason VARCHAR(500),
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attendance_overrides_attendance_id ON attendance_overrides(attendance_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS attendance_overrides;
+
+ALTER TABLE attendance
+    DROP COLUMN IF EXISTS overridden_at,
+    DROP COLUMN IF EXISTS overridden_by;
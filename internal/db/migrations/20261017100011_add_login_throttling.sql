@@ -0,0 +1,26 @@
+-- migrate:up
+-- Failed sign-in counters. Keys are "account:<email>" or "ip:<address>" so
+-- unknown emails are throttled exactly like registered ones.
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP
+);
+
+CREATE INDEX idx_login_throttles_last_failure_at ON login_throttles(last_failure_at);
+
+CREATE TABLE account_unlock_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_account_unlock_tokens_user_id ON account_unlock_tokens(user_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS account_unlock_tokens;
+DROP TABLE IF EXISTS login_throttles;
@@ -0,0 +1,24 @@
+-- migrate:up
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('teacher', 'student', 'admin'));
+
+ALTER TABLE users
+    ADD COLUMN disabled_at TIMESTAMP,
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- A deleted user's access tokens must stay revoked until they expire, so the
+-- cutoff outlives the user row. Stale cutoffs are purged by the cleanup job.
+ALTER TABLE user_token_revocations DROP CONSTRAINT user_token_revocations_user_id_fkey;
+
+-- migrate:down
+DELETE FROM user_token_revocations WHERE user_id NOT IN (SELECT id FROM users);
+ALTER TABLE user_token_revocations
+    ADD CONSTRAINT user_token_revocations_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_reset_required,
+    DROP COLUMN IF EXISTS disabled_at;
+
+DELETE FROM users WHERE role = 'admin';
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('teacher', 'student'));
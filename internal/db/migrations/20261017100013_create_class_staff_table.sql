@@ -0,0 +1,18 @@
+-- migrate:up
+-- Class-scoped role assignments. Students are not listed here; their class
+-- role comes from enrollments.
+CREATE TABLE class_staff (
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_teacher', 'assistant')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE INDEX idx_class_staff_user_id ON class_staff(user_id);
+
+INSERT INTO class_staff (class_id, user_id, role, created_at)
+SELECT id, teacher_id, 'owner', created_at FROM classes;
+
+-- migrate:down
+DROP TABLE IF EXISTS class_staff;
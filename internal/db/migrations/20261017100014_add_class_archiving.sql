@@ -0,0 +1,7 @@
+-- migrate:up
+-- Archived classes keep their enrollments, sessions and attendance but are
+-- hidden from active lists and closed to new enrollments and sessions.
+ALTER TABLE classes ADD COLUMN archived_at TIMESTAMP;
+
+-- migrate:down
+ALTER TABLE classes DROP COLUMN IF EXISTS archived_at;
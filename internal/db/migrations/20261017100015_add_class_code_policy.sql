@@ -0,0 +1,15 @@
+-- migrate:up
+-- Joining by code can be switched off, limited in time or capped in uses.
+-- code_uses counts joins since the code was last regenerated.
+ALTER TABLE classes
+    ADD COLUMN code_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN code_expires_at TIMESTAMP,
+    ADD COLUMN code_max_uses INTEGER CHECK (code_max_uses > 0),
+    ADD COLUMN code_uses INTEGER NOT NULL DEFAULT 0;
+
+-- migrate:down
+ALTER TABLE classes
+    DROP COLUMN IF EXISTS code_enabled,
+    DROP COLUMN IF EXISTS code_expires_at,
+    DROP COLUMN IF EXISTS code_max_uses,
+    DROP COLUMN IF EXISTS code_uses;
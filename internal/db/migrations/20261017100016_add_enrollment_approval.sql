@@ -0,0 +1,20 @@
+-- migrate:up
+-- Only active enrollments are on the roster. Pending ones wait for a teacher
+-- to approve them; waitlisted ones wait for a seat, oldest first.
+ALTER TABLE enrollments
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'pending', 'waitlisted'));
+
+CREATE INDEX idx_enrollments_class_status ON enrollments(class_id, status, enrolled_at);
+
+ALTER TABLE classes
+    ADD COLUMN enrollment_requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN capacity INTEGER CHECK (capacity > 0);
+
+-- migrate:down
+ALTER TABLE classes
+    DROP COLUMN IF EXISTS enrollment_requires_approval,
+    DROP COLUMN IF EXISTS capacity;
+
+DROP INDEX IF EXISTS idx_enrollments_class_status;
+ALTER TABLE enrollments DROP COLUMN IF EXISTS status;
@@ -0,0 +1,7 @@
+-- migrate:up
+-- The registrar's identifier, set by roster imports. Not unique: rosters
+-- from different registrars may reuse numbers.
+ALTER TABLE users ADD COLUMN student_number VARCHAR(50);
+
+-- migrate:down
+ALTER TABLE users DROP COLUMN IF EXISTS student_number;
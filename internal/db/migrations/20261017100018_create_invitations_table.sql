@@ -0,0 +1,20 @@
+-- migrate:up
+-- An emailed invitation to join a class. Only the SHA-256 hash of the token
+-- is stored; an invitation is outstanding until accepted, revoked or expired.
+CREATE TABLE invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_invitations_class_email ON invitations(class_id, email);
+
+-- migrate:down
+DROP TABLE IF EXISTS invitations;
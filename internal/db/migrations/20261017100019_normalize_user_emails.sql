@@ -0,0 +1,19 @@
+-- migrate:up
+-- Accounts registered before emails were normalized may differ only in case.
+-- The oldest keeps the address; the others are disabled and parked on a
+-- placeholder address so an admin can sort them out.
+UPDATE users u
+SET email = 'duplicate+' || u.id || '@invalid',
+    disabled_at = COALESCE(u.disabled_at, NOW())
+WHERE EXISTS (
+    SELECT 1 FROM users o
+    WHERE lower(trim(o.email)) = lower(trim(u.email))
+      AND (o.created_at, o.id) < (u.created_at, u.id)
+);
+
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+
+CREATE UNIQUE INDEX idx_users_email_lower ON users(lower(email));
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_users_email_lower;
@@ -0,0 +1,17 @@
+-- migrate:up
+-- Deleting whoever opened a session must not take the session and its
+-- attendance with it.
+ALTER TABLE class_sessions ALTER COLUMN opened_by DROP NOT NULL;
+ALTER TABLE class_sessions DROP CONSTRAINT class_sessions_opened_by_fkey;
+ALTER TABLE class_sessions
+    ADD CONSTRAINT class_sessions_opened_by_fkey FOREIGN KEY (opened_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- migrate:down
+ALTER TABLE class_sessions DROP CONSTRAINT class_sessions_opened_by_fkey;
+UPDATE class_sessions s
+SET opened_by = c.teacher_id
+FROM classes c
+WHERE c.id = s.class_id AND s.opened_by IS NULL;
+ALTER TABLE class_sessions ALTER COLUMN opened_by SET NOT NULL;
+ALTER TABLE class_sessions
+    ADD CONSTRAINT class_sessions_opened_by_fkey FOREIGN KEY (opened_by) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+type AdminHandler struct {
+	auditService service.AuditService
+	userService  service.UserService
+	validate     *validator.Validate
+	logger       zerolog.Logger
+}
+
+func NewAdminHandler(
+	auditService service.AuditService, userService service.UserService, logger zerolog.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		auditService: auditService,
+		userService:  userService,
+		validate:     validator.New(),
+		logger:       logger,
+	}
+}
+
+// ListAuditEvents handles GET /api/v1/admin/audit-events
+// Query: actor_id, entity_type, entity_id, from, to (RFC 3339), limit, offset.
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	filter, message := parseAuditEventFilter(c)
+	if message != "" {
+		BadRequest(c, message)
+		return
+	}
+
+	page, err := h.auditService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to list audit events")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, page)
+}
+
+// ListUsers handles GET /api/v1/admin/users
+// Query: q (email or name substring), role, disabled (true/false), limit, offset.
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	filter := &models.UserFilter{
+		Query: c.Query("q"),
+		Role:  models.Role(c.Query("role")),
+	}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		BadRequest(c, "role must be one of: teacher student admin")
+		return
+	}
+
+	if v := c.Query("disabled"); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			BadRequest(c, "disabled must be true or false")
+			return
+		}
+		filter.Disabled = &disabled
+	}
+
+	var message string
+	if filter.Limit, filter.Offset, message = parsePagination(c); message != "" {
+		BadRequest(c, message)
+		return
+	}
+
+	page, err := h.userService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to list users")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, page)
+}
+
+// GetUser handles GET /api/v1/admin/users/:id
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		h.handleUserError(c, err, userID, "Failed to get user")
+		return
+	}
+
+	Success(c, http.StatusOK, user.ToResponse())
+}
+
+// ChangeRole handles PUT /api/v1/admin/users/:id/role
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	var input models.ChangeRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	user, err := h.userService.ChangeRole(c.Request.Context(), middleware.GetUserID(c), userID, input.Role)
+	if err != nil {
+		h.handleUserError(c, err, userID, "Failed to change role")
+		return
+	}
+
+	Success(c, http.StatusOK, user.ToResponse())
+}
+
+// Disable handles POST /api/v1/admin/users/:id/disable
+func (h *AdminHandler) Disable(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	user, err := h.userService.Disable(c.Request.Context(), middleware.GetUserID(c), userID)
+	if err != nil {
+		h.handleUserError(c, err, userID, "Failed to disable user")
+		return
+	}
+
+	Success(c, http.StatusOK, user.ToResponse())
+}
+
+// Enable handles POST /api/v1/admin/users/:id/enable
+func (h *AdminHandler) Enable(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	user, err := h.userService.Enable(c.Request.Context(), userID)
+	if err != nil {
+		h.handleUserError(c, err, userID, "Failed to enable user")
+		return
+	}
+
+	Success(c, http.StatusOK, user.ToResponse())
+}
+
+// ForcePasswordReset handles POST /api/v1/admin/users/:id/password-reset
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	if err := h.userService.ForcePasswordReset(c.Request.Context(), userID); err != nil {
+		h.handleUserError(c, err, userID, "Failed to force password reset")
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "password reset required; a reset link has been emailed"})
+}
+
+// DeleteUser handles DELETE /api/v1/admin/users/:id
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	if err := h.userService.Delete(c.Request.Context(), middleware.GetUserID(c), userID); err != nil {
+		h.handleUserError(c, err, userID, "Failed to delete user")
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "user deleted"})
+}
+
+func (h *AdminHandler) handleUserError(c *gin.Context, err error, userID uuid.UUID, logMessage string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFound(c, "user not found")
+	case errors.Is(err, service.ErrCannotModifySelf):
+		Forbidden(c, "admins cannot change their own role, disable or delete themselves")
+	case errors.Is(err, service.ErrUserOwnsClasses):
+		Error(c, http.StatusConflict, "user owns classes; delete them first or disable the account instead")
+	case errors.Is(err, service.ErrUserTeachesClasses):
+		Error(c, http.StatusConflict, "user owns or co-teaches classes; remove them from class staff first")
+	default:
+		h.logger.Error().Err(err).Str("user_id", userID.String()).Msg(logMessage)
+		InternalError(c)
+	}
+}
+
+// parseAuditEventFilter reads the query string, returning a message on bad input.
+func parseAuditEventFilter(c *gin.Context) (*models.AuditEventFilter, string) {
+	filter := &models.AuditEventFilter{
+		EntityType: c.Query("entity_type"),
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, "invalid actor_id"
+		}
+		filter.ActorID = &id
+	}
+
+	if v := c.Query("entity_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, "invalid entity_id"
+		}
+		filter.EntityID = &id
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, "from must be an RFC 3339 timestamp"
+		}
+		filter.From = &from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, "to must be an RFC 3339 timestamp"
+		}
+		filter.To = &to
+	}
+
+	var message string
+	if filter.Limit, filter.Offset, message = parsePagination(c); message != "" {
+		return nil, message
+	}
+
+	return filter, ""
+}
+
+// parsePagination reads limit and offset, returning a message on bad input.
+// Zero values leave the choice to the service.
+func parsePagination(c *gin.Context) (limit, offset int, message string) {
+	var err error
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return 0, 0, "limit must be a positive integer"
+		}
+	}
+
+	if v := c.Query("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, "offset must be a non-negative integer"
+		}
+	}
+
+	return limit, offset, ""
+}
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+type AttendanceHandler struct {
+	attendanceService service.AttendanceService
+	validate          *validator.Validate
+	logger            zerolog.Logger
+}
+
+func NewAttendanceHandler(attendanceService service.AttendanceService, logger zerolog.Logger) *AttendanceHandler {
+	return &AttendanceHandler{
+		attendanceService: attendanceService,
+		validate:          validator.New(),
+		logger:            logger,
+	}
+}
+
+// Mark handles POST /api/v1/classes/:id/attendance
+// Student marks themselves present in the class's open session
+// by submitting the check-in code currently shown by the teacher.
+func (h *AttendanceHandler) Mark(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	var input models.MarkAttendanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	studentID := middleware.GetUserID(c)
+	attendance, err := h.attendanceService.MarkAttendance(c.Request.Context(), classID, studentID, &input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrClassNotFound):
+			NotFound(c, "class not found")
+		case errors.Is(err, service.ErrNotEnrolled):
+			Forbidden(c, "not enrolled in this class")
+		case errors.Is(err, service.ErrNoOpenSession):
+			Error(c, http.StatusConflict, "no open session for this class")
+		case errors.Is(err, service.ErrInvalidCheckinCode):
+			Forbidden(c, "invalid or expired check-in code")
+		case errors.Is(err, service.ErrCheckinLocked):
+			Error(c, http.StatusTooManyRequests, "too many wrong check-in codes; ask your teacher to mark you")
+		case errors.Is(err, service.ErrLocationRequired):
+			BadRequest(c, "location is required to mark attendance in this class")
+		case errors.Is(err, service.ErrOutsideGeofence):
+			Forbidden(c, "location is outside the class area")
+		case errors.Is(err, service.ErrAlreadyMarked):
+			Error(c, http.StatusConflict, "attendance already marked for this session")
+		default:
+			h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to mark attendance")
+			InternalError(c)
+		}
+		return
+	}
+
+	h.logger.Info().
+		Str("student_id", studentID.String()).
+		Str("class_id", classID.String()).
+		Msg("attendance marked")
+
+	Success(c, http.StatusCreated, attendance.ToResponse())
+}
+
+// ListForSession handles GET /api/v1/classes/:id/sessions/:sessionId/attendance
+// Returns the students who attended a session (teacher only).
+func (h *AttendanceHandler) ListForSession(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	sessionIDStr := c.Param("sessionId")
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		BadRequest(c, "invalid session ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	records, err := h.attendanceService.GetSessionAttendance(c.Request.Context(), teacherID, classID, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrClassNotFound):
+			NotFound(c, "class not found")
+		case errors.Is(err, service.ErrSessionNotFound):
+			NotFound(c, "session not found")
+		case errors.Is(err, service.ErrClassPermissionDenied):
+			Forbidden(c, "insufficient permissions for this class")
+		default:
+			h.logger.Error().Err(err).Str("session_id", sessionIDStr).Msg("failed to list attendance")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, records)
+}
+
+// Set handles PUT /api/v1/classes/:id/sessions/:sessionId/attendance/:studentId
+// Teacher overrides one student's status for a session.
+func (h *AttendanceHandler) Set(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		BadRequest(c, "invalid session ID")
+		return
+	}
+
+	studentIDStr := c.Param("studentId")
+	studentID, err := uuid.Parse(studentIDStr)
+	if err != nil {
+		BadRequest(c, "invalid student ID")
+		return
+	}
+
+	var input models.SetAttendanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	attendance, err := h.attendanceService.SetAttendance(
+		c.Request.Context(), teacherID, classID, sessionID, studentID, &input,
+	)
+	if err != nil {
+		if h.handleOverrideError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("student_id", studentIDStr).Msg("failed to set attendance")
+		InternalError(c)
+		return
+	}
+
+	h.logger.Info().
+		Str("teacher_id", teacherID.String()).
+		Str("student_id", studentIDStr).
+		Str("status", string(input.Status)).
+		Msg("attendance overridden")
+
+	Success(c, http.StatusOK, attendance.ToResponse())
+}
+
+// BulkSet handles PUT /api/v1/classes/:id/sessions/:sessionId/attendance
+// Teacher submits statuses for a whole roster; all records apply or none do.
+func (h *AttendanceHandler) BulkSet(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	sessionIDStr := c.Param("sessionId")
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		BadRequest(c, "invalid session ID")
+		return
+	}
+
+	var input models.BulkSetAttendanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	records, err := h.attendanceService.BulkSetAttendance(c.Request.Context(), teacherID, classID, sessionID, &input)
+	if err != nil {
+		if h.handleOverrideError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("session_id", sessionIDStr).Msg("failed to bulk set attendance")
+		InternalError(c)
+		return
+	}
+
+	h.logger.Info().
+		Str("teacher_id", teacherID.String()).
+		Str("session_id", sessionIDStr).
+		Int("count", len(records)).
+		Msg("attendance roster submitted")
+
+	response := make([]models.AttendanceResponse, len(records))
+	for i, record := range records {
+		response[i] = record.ToResponse()
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// handleOverrideError writes the response for known override errors and reports whether it did.
+func (h *AttendanceHandler) handleOverrideError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrSessionNotFound):
+		NotFound(c, "session not found")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrNotEnrolled), errors.Is(err, service.ErrDuplicateRosterEntry):
+		// The wrapped message names the offending student.
+		BadRequest(c, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// ListMine handles GET /api/v1/classes/:id/attendance/me
+// Returns the authenticated student's attendance history for a class.
+func (h *AttendanceHandler) ListMine(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	studentID := middleware.GetUserID(c)
+	records, err := h.attendanceService.GetStudentAttendance(c.Request.Context(), classID, studentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotEnrolled):
+			Forbidden(c, "not enrolled in this class")
+		default:
+			h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to get student attendance")
+			InternalError(c)
+		}
+		return
+	}
+
+	response := make([]models.AttendanceResponse, len(records))
+	for i, record := range records {
+		response[i] = record.ToResponse()
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// ListGeofenceViolations handles GET /api/v1/classes/:id/geofence/violations
+// Returns marking attempts rejected for being outside the class area (teacher only).
+func (h *AttendanceHandler) ListGeofenceViolations(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	violations, err := h.attendanceService.GetGeofenceViolations(c.Request.Context(), teacherID, classID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrClassNotFound):
+			NotFound(c, "class not found")
+		case errors.Is(err, service.ErrClassPermissionDenied):
+			Forbidden(c, "insufficient permissions for this class")
+		default:
+			h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to list geofence violations")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, violations)
+}
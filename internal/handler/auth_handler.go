@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
 	"github.com/tahiriqbal095/attendify/internal/models"
 	"github.com/tahiriqbal095/attendify/internal/service"
 )
@@ -37,7 +38,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if !input.Role.IsValid() {
+	// Admins are never self-registered.
+	if input.Role != models.RoleTeacher && input.Role != models.RoleStudent {
 		BadRequest(c, "role must be 'teacher' or 'student'")
 		return
 	}
@@ -68,12 +70,28 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.Login(c.Request.Context(), &input)
+	response, err := h.authService.Login(c.Request.Context(), &input, c.ClientIP())
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			Unauthorized(c, "invalid email or password")
 			return
 		}
+		if errors.Is(err, service.ErrLoginThrottled) {
+			Error(c, http.StatusTooManyRequests, "too many failed sign-in attempts; try again later")
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			Forbidden(c, "verify your email address before signing in")
+			return
+		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			Forbidden(c, "account disabled")
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetRequired) {
+			Forbidden(c, "password reset required; use the link emailed to you")
+			return
+		}
 		h.logger.Error().Err(err).Str("email", input.Email).Msg("Failed to login user")
 		InternalError(c)
 		return
@@ -82,13 +100,313 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	Success(c, http.StatusOK, response)
 }
 
+// Refresh handles POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input models.RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	response, err := h.authService.Refresh(c.Request.Context(), input.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			Unauthorized(c, "invalid or expired refresh token")
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			h.logger.Warn().Msg("Refresh token reuse detected; token family revoked")
+			Unauthorized(c, "invalid or expired refresh token")
+		default:
+			h.logger.Error().Err(err).Msg("Failed to refresh token")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// Logout handles POST /api/v1/auth/logout
+// The body is optional; include the refresh token to end the session for good.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var input models.LogoutInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			BadRequest(c, "invalid request body")
+			return
+		}
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	claims := middleware.GetClaims(c)
+	if err := h.authService.Logout(c.Request.Context(), claims, input.RefreshToken); err != nil {
+		h.logger.Error().Err(err).Str("user_id", claims.UserID.String()).Msg("Failed to logout user")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// RequestPasswordReset handles POST /api/v1/auth/password-reset
+// Always answers 200 so the response does not reveal whether the email is registered.
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var input models.RequestPasswordResetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to request password reset")
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "if the account exists, a reset link has been emailed"})
+}
+
+// ConfirmPasswordReset handles POST /api/v1/auth/password-reset/confirm
+func (h *AuthHandler) ConfirmPasswordReset(c *gin.Context) {
+	var input models.ConfirmPasswordResetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	if err := h.authService.ConfirmPasswordReset(c.Request.Context(), &input); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			BadRequest(c, "invalid or expired reset token")
+			return
+		}
+		h.logger.Error().Err(err).Msg("Failed to reset password")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "password updated; sign in again"})
+}
+
+// VerifyEmail handles POST /api/v1/auth/verify-email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	if err := h.authService.VerifyEmail(c.Request.Context(), input.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			BadRequest(c, "invalid or expired verification token")
+			return
+		}
+		h.logger.Error().Err(err).Msg("Failed to verify email")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification handles POST /api/v1/auth/verify-email/resend
+// Like RequestPasswordReset it always answers 200, even when throttled,
+// so the response does not reveal whether the email is registered.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var input models.ResendVerificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	err := h.authService.ResendVerification(c.Request.Context(), input.Email)
+	switch {
+	case errors.Is(err, service.ErrVerificationThrottled):
+		h.logger.Info().Msg("Verification email throttled")
+	case err != nil:
+		h.logger.Error().Err(err).Msg("Failed to resend verification email")
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "if the account exists and is unverified, a verification link has been emailed"})
+}
+
+// UnlockAccount handles POST /api/v1/auth/unlock
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var input models.UnlockAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	if err := h.authService.UnlockAccount(c.Request.Context(), input.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidUnlockToken) {
+			BadRequest(c, "invalid or expired unlock link")
+			return
+		}
+		h.logger.Error().Err(err).Msg("Failed to unlock account")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// VerifyMFA handles POST /api/v1/auth/mfa/verify
+// It completes a login that answered with an mfa_challenge.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var input models.VerifyMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	response, err := h.authService.VerifyMFA(c.Request.Context(), &input, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMFAChallenge):
+			Unauthorized(c, "invalid or expired two-factor challenge; sign in again")
+		case errors.Is(err, service.ErrInvalidMFACode):
+			Unauthorized(c, "invalid authentication code")
+		case errors.Is(err, service.ErrLoginThrottled):
+			Error(c, http.StatusTooManyRequests, "too many failed sign-in attempts; try again later")
+		default:
+			h.logger.Error().Err(err).Msg("Failed to verify mfa code")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// EnrollMFA handles POST /api/v1/auth/mfa/enroll
+// Calling it again before confirming replaces the pending secret.
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	response, err := h.authService.EnrollMFA(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			Error(c, http.StatusConflict, "two-factor authentication already enabled")
+			return
+		}
+		h.logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to enroll mfa")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// ConfirmMFA handles POST /api/v1/auth/mfa/confirm
+// The response holds the recovery codes; they are not shown again.
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	var input models.ConfirmMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	response, err := h.authService.ConfirmMFA(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMFACode):
+			BadRequest(c, "invalid authentication code")
+		case errors.Is(err, service.ErrMFASetupNotStarted):
+			BadRequest(c, "start two-factor enrollment first")
+		case errors.Is(err, service.ErrMFAAlreadyEnabled):
+			Error(c, http.StatusConflict, "two-factor authentication already enabled")
+		default:
+			h.logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to confirm mfa")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// DisableMFA handles DELETE /api/v1/auth/mfa
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var input models.DisableMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.authService.DisableMFA(c.Request.Context(), userID, input.Code); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMFARequired):
+			Forbidden(c, "two-factor authentication is required for your role")
+		case errors.Is(err, service.ErrMFANotEnabled):
+			BadRequest(c, "two-factor authentication not enabled")
+		case errors.Is(err, service.ErrInvalidMFACode):
+			BadRequest(c, "invalid authentication code")
+		default:
+			h.logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to disable mfa")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
 // formatValidationError converts validation errors to user-friendly messages.
 func formatValidationError(err error) string {
 	var validationErrors validator.ValidationErrors
 	if errors.As(err, &validationErrors) {
 		for _, e := range validationErrors {
 			switch e.Tag() {
-			case "required":
+			case "required", "required_if", "required_with", "required_without":
 				return e.Field() + " is required"
 			case "email":
 				return "invalid email format"
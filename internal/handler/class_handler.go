@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -50,9 +52,20 @@ func (h *ClassHandler) Create(c *gin.Context) {
 	Success(c, http.StatusCreated, class.ToResponse())
 }
 
+// List handles GET /api/v1/classes
+// Returns the classes the user teaches or assists. Query: archived (true/false).
 func (h *ClassHandler) List(c *gin.Context) {
+	var archived bool
+	if v := c.Query("archived"); v != "" {
+		var err error
+		if archived, err = strconv.ParseBool(v); err != nil {
+			BadRequest(c, "archived must be true or false")
+			return
+		}
+	}
+
 	teacherID := middleware.GetUserID(c)
-	classes, err := h.classService.GetTeacherClasses(c.Request.Context(), teacherID)
+	classes, err := h.classService.GetTeacherClasses(c.Request.Context(), teacherID, archived)
 	if err != nil {
 		h.logger.Error().Err(err).Str("teacher_id", teacherID.String()).Msg("Failed to list classes")
 		InternalError(c)
@@ -76,14 +89,12 @@ func (h *ClassHandler) Get(c *gin.Context) {
 		return
 	}
 
-	class, err := h.classService.GetClass(c.Request.Context(), classID)
+	class, err := h.classService.GetClass(c.Request.Context(), middleware.GetUserID(c), classID)
 	if err != nil {
-		if errors.Is(err, service.ErrClassNotFound) {
-			NotFound(c, "class not found")
-			return
+		if !h.handleClassError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to get class")
+			InternalError(c)
 		}
-		h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to get class")
-		InternalError(c)
 		return
 	}
 
@@ -105,8 +116,8 @@ func (h *ClassHandler) Delete(c *gin.Context) {
 			NotFound(c, "class not found")
 			return
 		}
-		if errors.Is(err, service.ErrNotClassOwner) {
-			Forbidden(c, "not the owner of this class")
+		if errors.Is(err, service.ErrClassPermissionDenied) {
+			Forbidden(c, "insufficient permissions for this class")
 			return
 		}
 		h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to delete class")
@@ -116,3 +127,283 @@ func (h *ClassHandler) Delete(c *gin.Context) {
 
 	Success(c, http.StatusOK, gin.H{"message": "class deleted"})
 }
+
+func (h *ClassHandler) UpdateGeofence(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var input models.UpdateGeofenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	class, err := h.classService.UpdateGeofence(c.Request.Context(), teacherID, classID, &input)
+	if err != nil {
+		if errors.Is(err, service.ErrClassNotFound) {
+			NotFound(c, "class not found")
+			return
+		}
+		if errors.Is(err, service.ErrClassPermissionDenied) {
+			Forbidden(c, "insufficient permissions for this class")
+			return
+		}
+		if errors.Is(err, service.ErrGeofenceIncomplete) {
+			BadRequest(c, "latitude, longitude and radius_meters are required to enable the geofence")
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to update geofence")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+// Update handles PATCH /api/v1/classes/:id
+func (h *ClassHandler) Update(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var input models.UpdateClassInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	class, err := h.classService.UpdateClass(c.Request.Context(), middleware.GetUserID(c), classID, &input)
+	if err != nil {
+		if !h.handleClassError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to update class")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+// Archive handles POST /api/v1/classes/:id/archive
+func (h *ClassHandler) Archive(c *gin.Context) {
+	h.setArchived(c, h.classService.ArchiveClass)
+}
+
+// Restore handles POST /api/v1/classes/:id/restore
+func (h *ClassHandler) Restore(c *gin.Context) {
+	h.setArchived(c, h.classService.RestoreClass)
+}
+
+func (h *ClassHandler) setArchived(
+	c *gin.Context, change func(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error),
+) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	class, err := change(c.Request.Context(), middleware.GetUserID(c), classID)
+	if err != nil {
+		if !h.handleClassError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to change class archive state")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+// handleClassError writes the response for known class errors and reports whether it did.
+func (h *ClassHandler) handleClassError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrClassHasOpenSession):
+		Error(c, http.StatusConflict, "close the open session before archiving the class")
+	default:
+		return false
+	}
+	return true
+}
+
+// RegenerateCode handles POST /api/v1/classes/:id/code/regenerate
+// Replaces the join code; the old one stops working immediately.
+func (h *ClassHandler) RegenerateCode(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	class, err := h.classService.RegenerateCode(c.Request.Context(), middleware.GetUserID(c), classID)
+	if err != nil {
+		if !h.handleClassError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to regenerate class code")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+// UpdateCodePolicy handles PUT /api/v1/classes/:id/code
+// Enables or disables joining by code and sets an optional expiry and use limit.
+func (h *ClassHandler) UpdateCodePolicy(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var input models.UpdateCodePolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	class, err := h.classService.UpdateCodePolicy(c.Request.Context(), middleware.GetUserID(c), classID, &input)
+	if err != nil {
+		if !h.handleClassError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to update class code policy")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+// ListStaff handles GET /api/v1/classes/:id/staff
+func (h *ClassHandler) ListStaff(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	staff, err := h.classService.ListStaff(c.Request.Context(), middleware.GetUserID(c), classID)
+	if err != nil {
+		if !h.handleStaffError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to list class staff")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, staff)
+}
+
+// AddStaff handles POST /api/v1/classes/:id/staff
+// Adds an existing account as a co-teacher or assistant.
+func (h *ClassHandler) AddStaff(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var input models.AddStaffInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	member, err := h.classService.AddStaff(c.Request.Context(), middleware.GetUserID(c), classID, &input)
+	if err != nil {
+		if !h.handleStaffError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to add class staff")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusCreated, member)
+}
+
+// RemoveStaff handles DELETE /api/v1/classes/:id/staff/:userId
+func (h *ClassHandler) RemoveStaff(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	staffUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		BadRequest(c, "invalid user id")
+		return
+	}
+
+	err = h.classService.RemoveStaff(c.Request.Context(), middleware.GetUserID(c), classID, staffUserID)
+	if err != nil {
+		if !h.handleStaffError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to remove class staff")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "staff member removed"})
+}
+
+// handleStaffError writes the response for known staff errors and reports whether it did.
+func (h *ClassHandler) handleStaffError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFound(c, "no active account with that email")
+	case errors.Is(err, service.ErrStaffNotFound):
+		NotFound(c, "user is not on the staff of this class")
+	case errors.Is(err, service.ErrAlreadyClassMember):
+		Error(c, http.StatusConflict, "user is already a member of this class")
+	case errors.Is(err, service.ErrCannotRemoveOwner):
+		Error(c, http.StatusConflict, "the class owner cannot be removed")
+	case errors.Is(err, service.ErrCoTeacherNotTeacher):
+		BadRequest(c, "only teacher accounts can be co-teachers")
+	default:
+		return false
+	}
+	return true
+}
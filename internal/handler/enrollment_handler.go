@@ -8,31 +8,29 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
 	"github.com/tahiriqbal095/attendify/internal/models"
 	"github.com/tahiriqbal095/attendify/internal/service"
 )
 
 type EnrollmentHandler struct {
 	enrollmentService service.EnrollmentService
-	classService      service.ClassService
 	logger            zerolog.Logger
 	validate          *validator.Validate
 }
 
 func NewEnrollmentHandler(
 	enrollmentService service.EnrollmentService,
-	classService service.ClassService,
 	logger zerolog.Logger,
 ) *EnrollmentHandler {
 	return &EnrollmentHandler{
 		enrollmentService: enrollmentService,
-		classService:      classService,
 		logger:            logger,
 		validate:          validator.New(),
 	}
 }
 
-// EnrollByCode handles POST /api/enrollments
+// EnrollByCode handles POST /api/v1/enrollments
 // Students join a class using the class code.
 func (h *EnrollmentHandler) EnrollByCode(c *gin.Context) {
 	var input models.EnrollByCodeInput
@@ -48,19 +46,33 @@ func (h *EnrollmentHandler) EnrollByCode(c *gin.Context) {
 		return
 	}
 
-	studentID, exists := c.Get("userID")
-	if !exists {
+	studentID := middleware.GetUserID(c)
+	if studentID == uuid.Nil {
 		Unauthorized(c, "unauthorized")
 		return
 	}
 
-	enrollment, err := h.enrollmentService.EnrollByCode(c.Request.Context(), input.ClassCode, studentID.(uuid.UUID))
+	enrollment, err := h.enrollmentService.EnrollByCode(c.Request.Context(), input.ClassCode, studentID)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrClassNotFound):
 			NotFound(c, "class not found")
 		case errors.Is(err, service.ErrAlreadyEnrolled):
 			Error(c, http.StatusConflict, "already enrolled in this class")
+		case errors.Is(err, service.ErrEnrollmentAlreadyRequested):
+			Error(c, http.StatusConflict, "already requested to join this class")
+		case errors.Is(err, service.ErrClassArchived):
+			Error(c, http.StatusConflict, "class is archived and not accepting enrollments")
+		case errors.Is(err, service.ErrClassCodeDisabled):
+			Forbidden(c, "joining this class by code is disabled")
+		case errors.Is(err, service.ErrClassCodeExpired):
+			Error(c, http.StatusGone, "class code has expired")
+		case errors.Is(err, service.ErrClassCodeExhausted):
+			Error(c, http.StatusGone, "class code has no uses left")
+		case errors.Is(err, service.ErrAlreadyClassMember):
+			Error(c, http.StatusConflict, "you are on the staff of this class")
+		case errors.Is(err, service.ErrEmailNotVerified):
+			Forbidden(c, "verify your email address before joining a class")
 		default:
 			h.logger.Error().Err(err).Msg("failed to enroll student")
 			InternalError(c)
@@ -69,23 +81,29 @@ func (h *EnrollmentHandler) EnrollByCode(c *gin.Context) {
 	}
 
 	h.logger.Info().
-		Str("student_id", studentID.(uuid.UUID).String()).
+		Str("student_id", studentID.String()).
 		Str("class_id", enrollment.ClassID.String()).
+		Str("status", string(enrollment.Status)).
 		Msg("student enrolled successfully")
 
-	Success(c, http.StatusCreated, enrollment)
+	// Pending and waitlisted enrollments are not yet a seat in the class.
+	status := http.StatusCreated
+	if enrollment.Status != models.EnrollmentActive {
+		status = http.StatusAccepted
+	}
+	Success(c, status, enrollment)
 }
 
-// GetMyClasses handles GET /api/enrollments
+// GetMyClasses handles GET /api/v1/enrollments
 // Returns all classes the authenticated student is enrolled in.
 func (h *EnrollmentHandler) GetMyClasses(c *gin.Context) {
-	studentID, exists := c.Get("userID")
-	if !exists {
+	studentID := middleware.GetUserID(c)
+	if studentID == uuid.Nil {
 		Unauthorized(c, "unauthorized")
 		return
 	}
 
-	classes, err := h.enrollmentService.GetStudentClasses(c.Request.Context(), studentID.(uuid.UUID))
+	classes, err := h.enrollmentService.GetStudentClasses(c.Request.Context(), studentID)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to get enrolled classes")
 		InternalError(c)
@@ -95,8 +113,27 @@ func (h *EnrollmentHandler) GetMyClasses(c *gin.Context) {
 	Success(c, http.StatusOK, classes)
 }
 
-// GetClassStudents handles GET /api/classes/:id/students
-// Returns all students enrolled in a class (teacher only).
+// GetMyRequests handles GET /api/v1/enrollments/requests
+// Returns the authenticated student's pending and waitlisted enrollments.
+func (h *EnrollmentHandler) GetMyRequests(c *gin.Context) {
+	studentID := middleware.GetUserID(c)
+	if studentID == uuid.Nil {
+		Unauthorized(c, "unauthorized")
+		return
+	}
+
+	requests, err := h.enrollmentService.GetStudentRequests(c.Request.Context(), studentID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to get enrollment requests")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, requests)
+}
+
+// GetClassStudents handles GET /api/v1/classes/:id/students
+// Returns all students enrolled in a class (class staff only).
 func (h *EnrollmentHandler) GetClassStudents(c *gin.Context) {
 	classIDStr := c.Param("id")
 	classID, err := uuid.Parse(classIDStr)
@@ -105,40 +142,30 @@ func (h *EnrollmentHandler) GetClassStudents(c *gin.Context) {
 		return
 	}
 
-	// Verify teacher owns this class
-	teacherID, exists := c.Get("userID")
-	if !exists {
+	userID := middleware.GetUserID(c)
+	if userID == uuid.Nil {
 		Unauthorized(c, "unauthorized")
 		return
 	}
 
-	class, err := h.classService.GetClass(c.Request.Context(), classID)
+	students, err := h.enrollmentService.GetClassStudents(c.Request.Context(), userID, classID)
 	if err != nil {
-		if errors.Is(err, service.ErrClassNotFound) {
+		switch {
+		case errors.Is(err, service.ErrClassNotFound):
 			NotFound(c, "class not found")
-			return
+		case errors.Is(err, service.ErrClassPermissionDenied):
+			Forbidden(c, "insufficient permissions for this class")
+		default:
+			h.logger.Error().Err(err).Msg("failed to get class students")
+			InternalError(c)
 		}
-		h.logger.Error().Err(err).Msg("failed to get class")
-		InternalError(c)
-		return
-	}
-
-	if class.TeacherID != teacherID.(uuid.UUID) {
-		Forbidden(c, "access denied")
-		return
-	}
-
-	students, err := h.enrollmentService.GetClassStudents(c.Request.Context(), classID)
-	if err != nil {
-		h.logger.Error().Err(err).Msg("failed to get class students")
-		InternalError(c)
 		return
 	}
 
 	Success(c, http.StatusOK, students)
 }
 
-// Unenroll handles DELETE /api/enrollments/:classId
+// Unenroll handles DELETE /api/v1/enrollments/:classId
 // Student leaves a class.
 func (h *EnrollmentHandler) Unenroll(c *gin.Context) {
 	classIDStr := c.Param("classId")
@@ -148,13 +175,13 @@ func (h *EnrollmentHandler) Unenroll(c *gin.Context) {
 		return
 	}
 
-	studentID, exists := c.Get("userID")
-	if !exists {
+	studentID := middleware.GetUserID(c)
+	if studentID == uuid.Nil {
 		Unauthorized(c, "unauthorized")
 		return
 	}
 
-	err = h.enrollmentService.Unenroll(c.Request.Context(), classID, studentID.(uuid.UUID))
+	err = h.enrollmentService.Unenroll(c.Request.Context(), classID, studentID)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNotEnrolled):
@@ -167,9 +194,141 @@ func (h *EnrollmentHandler) Unenroll(c *gin.Context) {
 	}
 
 	h.logger.Info().
-		Str("student_id", studentID.(uuid.UUID).String()).
+		Str("student_id", studentID.String()).
 		Str("class_id", classID.String()).
 		Msg("student unenrolled successfully")
 
 	Success(c, http.StatusOK, nil)
 }
+
+// ListRequests handles GET /api/v1/classes/:id/enrollment-requests
+// Returns pending and waitlisted enrollments, optionally filtered by ?status=.
+// Waitlisted entries come in waitlist order.
+func (h *EnrollmentHandler) ListRequests(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	status := models.EnrollmentStatus(c.Query("status"))
+	if status != "" && (status == models.EnrollmentActive || !status.IsValid()) {
+		BadRequest(c, "status must be pending or waitlisted")
+		return
+	}
+
+	requests, err := h.enrollmentService.ListRequests(c.Request.Context(), middleware.GetUserID(c), classID, status)
+	if err != nil {
+		if !h.handleRequestError(c, err) {
+			h.logger.Error().Err(err).Msg("failed to list enrollment requests")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, requests)
+}
+
+// Approve handles POST /api/v1/classes/:id/enrollment-requests/:studentId/approve
+// The student is enrolled, or waitlisted if the class is full.
+func (h *EnrollmentHandler) Approve(c *gin.Context) {
+	classID, studentID, ok := h.parseRequestParams(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.enrollmentService.Approve(c.Request.Context(), middleware.GetUserID(c), classID, studentID)
+	if err != nil {
+		if !h.handleRequestError(c, err) {
+			h.logger.Error().Err(err).Msg("failed to approve enrollment request")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, enrollment)
+}
+
+// Reject handles POST /api/v1/classes/:id/enrollment-requests/:studentId/reject
+// Drops a pending request or removes the student from the waitlist.
+func (h *EnrollmentHandler) Reject(c *gin.Context) {
+	classID, studentID, ok := h.parseRequestParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.enrollmentService.Reject(c.Request.Context(), middleware.GetUserID(c), classID, studentID); err != nil {
+		if !h.handleRequestError(c, err) {
+			h.logger.Error().Err(err).Msg("failed to reject enrollment request")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, nil)
+}
+
+// UpdatePolicy handles PUT /api/v1/classes/:id/enrollment-policy
+// Sets whether joining needs approval and the optional capacity.
+func (h *EnrollmentHandler) UpdatePolicy(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	var input models.UpdateEnrollmentPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	class, err := h.enrollmentService.UpdatePolicy(c.Request.Context(), middleware.GetUserID(c), classID, &input)
+	if err != nil {
+		if !h.handleRequestError(c, err) {
+			h.logger.Error().Err(err).Msg("failed to update enrollment policy")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, class.ToResponse())
+}
+
+func (h *EnrollmentHandler) parseRequestParams(c *gin.Context) (classID, studentID uuid.UUID, ok bool) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	studentID, err = uuid.Parse(c.Param("studentId"))
+	if err != nil {
+		BadRequest(c, "invalid student ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return classID, studentID, true
+}
+
+// handleRequestError writes the response for known enrollment request errors
+// and reports whether it did.
+func (h *EnrollmentHandler) handleRequestError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrEnrollmentRequestNotFound):
+		NotFound(c, "enrollment request not found")
+	case errors.Is(err, service.ErrClassArchived):
+		Error(c, http.StatusConflict, "class is archived and not accepting enrollments")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+type InvitationHandler struct {
+	invitationService service.InvitationService
+	logger            zerolog.Logger
+	validate          *validator.Validate
+}
+
+func NewInvitationHandler(invitationService service.InvitationService, logger zerolog.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		logger:            logger,
+		validate:          validator.New(),
+	}
+}
+
+// Create handles POST /api/v1/classes/:id/invitations
+// Emails an invitation to each address, replacing any outstanding one.
+func (h *InvitationHandler) Create(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var input models.CreateInvitationsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	invitations, err := h.invitationService.Invite(c.Request.Context(), middleware.GetUserID(c), classID, input.Emails)
+	if err != nil {
+		if !h.handleError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to send invitations")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusCreated, invitations)
+}
+
+// List handles GET /api/v1/classes/:id/invitations
+// Returns the class's invitations that can still be accepted.
+func (h *InvitationHandler) List(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	invitations, err := h.invitationService.ListOutstanding(c.Request.Context(), middleware.GetUserID(c), classID)
+	if err != nil {
+		if !h.handleError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to list invitations")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, invitations)
+}
+
+// Revoke handles DELETE /api/v1/classes/:id/invitations/:invitationId
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		BadRequest(c, "invalid invitation id")
+		return
+	}
+
+	err = h.invitationService.Revoke(c.Request.Context(), middleware.GetUserID(c), classID, invitationID)
+	if err != nil {
+		if !h.handleError(c, err) {
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to revoke invitation")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+// Accept handles POST /api/v1/invitations/accept
+// Enrolls the signed-in student the invitation was sent to.
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	var input models.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	enrollment, err := h.invitationService.Accept(c.Request.Context(), middleware.GetUserID(c), input.Token)
+	if err != nil {
+		if !h.handleError(c, err) {
+			h.logger.Error().Err(err).Msg("Failed to accept invitation")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusCreated, enrollment)
+}
+
+// AcceptWithSignup handles POST /api/v1/invitations/accept/signup
+// Creates a student account for the invited address and enrolls it. The
+// student then signs in as usual.
+func (h *InvitationHandler) AcceptWithSignup(c *gin.Context) {
+	var input models.AcceptInvitationWithSignupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	response, err := h.invitationService.AcceptWithSignup(c.Request.Context(), &input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailTaken):
+			Error(c, http.StatusConflict, "an account with this email already exists; sign in to accept")
+		case h.handleError(c, err):
+		default:
+			h.logger.Error().Err(err).Msg("Failed to accept invitation with signup")
+			InternalError(c)
+		}
+		return
+	}
+
+	Success(c, http.StatusCreated, response)
+}
+
+// handleError writes the response for known invitation errors and reports whether it did.
+func (h *InvitationHandler) handleError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrClassArchived):
+		Error(c, http.StatusConflict, "class is archived and not accepting enrollments")
+	case errors.Is(err, service.ErrInvitationNotFound):
+		NotFound(c, "invitation not found")
+	case errors.Is(err, service.ErrInvalidInvitation):
+		BadRequest(c, "invalid or expired invitation")
+	case errors.Is(err, service.ErrInvitationEmailMismatch):
+		Forbidden(c, "this invitation was sent to a different email address")
+	case errors.Is(err, service.ErrAlreadyEnrolled):
+		Error(c, http.StatusConflict, "already enrolled in this class")
+	case errors.Is(err, service.ErrAlreadyClassMember):
+		Error(c, http.StatusConflict, "you are on the staff of this class")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tahiriqbal095/attendify/internal/jwtkeys"
+)
+
+// jwksMaxAge lets verifiers cache the key set briefly; a newly added
+// verification key should be deployed at least this long before signing with it.
+const jwksMaxAge = "public, max-age=300"
+
+type JWKSHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Get handles GET /.well-known/jwks.json
+// The body is a bare JWK Set as defined by RFC 7517, not the usual envelope.
+func (h *JWKSHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+// maxRosterBytes bounds an uploaded roster file.
+const maxRosterBytes = 1 << 20
+
+var errRosterTooLarge = fmt.Errorf("roster may have at most %d students", models.MaxRosterRows)
+
+type RosterHandler struct {
+	rosterService service.RosterService
+	logger        zerolog.Logger
+	validate      *validator.Validate
+}
+
+func NewRosterHandler(rosterService service.RosterService, logger zerolog.Logger) *RosterHandler {
+	return &RosterHandler{
+		rosterService: rosterService,
+		logger:        logger,
+		validate:      validator.New(),
+	}
+}
+
+// Import handles POST /api/v1/classes/:id/roster/import
+// Takes a CSV of email, name and an optional student number, either as the
+// request body or as the "file" field of a multipart form. A header row is
+// optional. With ?dry_run=true every row is checked and nothing is written.
+func (h *RosterHandler) Import(c *gin.Context) {
+	idParam := c.Param("id")
+	classID, err := uuid.Parse(idParam)
+	if err != nil {
+		BadRequest(c, "invalid class id")
+		return
+	}
+
+	var dryRun bool
+	if v := c.Query("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			BadRequest(c, "dry_run must be true or false")
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRosterBytes)
+	body, err := h.openRoster(c)
+	if err != nil {
+		if !h.handleRosterReadError(c, err) {
+			BadRequest(c, "missing roster file")
+		}
+		return
+	}
+	defer body.Close()
+
+	rows, err := parseRoster(body)
+	if err != nil {
+		if !h.handleRosterReadError(c, err) {
+			BadRequest(c, "invalid CSV: "+err.Error())
+		}
+		return
+	}
+	if len(rows) == 0 {
+		BadRequest(c, "roster has no students")
+		return
+	}
+	for i := range rows {
+		if len(rows[i].Errors) > 0 {
+			continue
+		}
+		if err := h.validate.Struct(&rows[i]); err != nil {
+			rows[i].Errors = append(rows[i].Errors, formatValidationError(err))
+		}
+	}
+
+	result, err := h.rosterService.Import(c.Request.Context(), middleware.GetUserID(c), classID, rows, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRosterInvalid):
+			c.JSON(http.StatusUnprocessableEntity, Response{Success: false, Data: result, Error: err.Error()})
+		case errors.Is(err, service.ErrClassNotFound):
+			NotFound(c, "class not found")
+		case errors.Is(err, service.ErrClassPermissionDenied):
+			Forbidden(c, "insufficient permissions for this class")
+		case errors.Is(err, service.ErrClassArchived):
+			Error(c, http.StatusConflict, "class is archived and not accepting enrollments")
+		case errors.Is(err, service.ErrRosterExceedsCapacity):
+			Error(c, http.StatusConflict, "roster would exceed the class capacity")
+		case errors.Is(err, service.ErrRosterChanged):
+			Error(c, http.StatusConflict, "accounts or enrollments changed during the import; try again")
+		default:
+			h.logger.Error().Err(err).Str("class_id", idParam).Msg("Failed to import roster")
+			InternalError(c)
+		}
+		return
+	}
+
+	if dryRun {
+		Success(c, http.StatusOK, result)
+		return
+	}
+
+	h.logger.Info().
+		Str("class_id", idParam).
+		Int("created", result.Created).
+		Int("enrolled", result.Enrolled+result.Activated).
+		Msg("Roster imported")
+
+	Success(c, http.StatusCreated, result)
+}
+
+// handleRosterReadError writes the response for an oversized roster and reports whether it did.
+func (h *RosterHandler) handleRosterReadError(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		Error(c, http.StatusRequestEntityTooLarge, "roster file is too large")
+	case errors.Is(err, errRosterTooLarge):
+		Error(c, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// openRoster returns the uploaded file of a multipart request, or the body otherwise.
+func (h *RosterHandler) openRoster(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		return file, err
+	}
+	return c.Request.Body, nil
+}
+
+// parseRoster reads roster rows, skipping a header row if the first cell is
+// "email". Rows with the wrong number of cells are kept with an error so
+// the report covers the whole file.
+func parseRoster(r io.Reader) ([]models.RosterRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []models.RosterRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			// Spreadsheet exports often start with a byte order mark.
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if strings.EqualFold(strings.TrimSpace(record[0]), "email") {
+				continue
+			}
+		}
+		if len(rows) == models.MaxRosterRows {
+			return nil, errRosterTooLarge
+		}
+
+		row := models.RosterRow{Line: line}
+		switch len(record) {
+		case 3:
+			row.StudentNumber = strings.TrimSpace(record[2])
+			fallthrough
+		case 2:
+			// Normalized once here; the import matches accounts and
+			// duplicates on this value.
+			row.Email = models.NormalizeEmail(record[0])
+			row.Name = strings.TrimSpace(record[1])
+		default:
+			row.Errors = []string{"expected email, name and an optional student number"}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+func TestParseRoster(t *testing.T) {
+	wrongFields := []string{"expected email, name and an optional student number"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []models.RosterRow
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "header only",
+			input: "email,name\n",
+			want:  nil,
+		},
+		{
+			name:  "without header",
+			input: "ada@example.com,Ada Lovelace\nalan@example.com,Alan Turing\n",
+			want: []models.RosterRow{
+				{Line: 1, Email: "ada@example.com", Name: "Ada Lovelace"},
+				{Line: 2, Email: "alan@example.com", Name: "Alan Turing"},
+			},
+		},
+		{
+			name:  "header with byte order mark",
+			input: "\ufeffEmail,Name,Student Number\nada@example.com,Ada Lovelace,S-001\n",
+			want: []models.RosterRow{
+				{Line: 2, Email: "ada@example.com", Name: "Ada Lovelace", StudentNumber: "S-001"},
+			},
+		},
+		{
+			name:  "emails normalized",
+			input: "  Ada@Example.COM , Ada Lovelace \n",
+			want: []models.RosterRow{
+				{Line: 1, Email: "ada@example.com", Name: "Ada Lovelace"},
+			},
+		},
+		{
+			name:  "quoted name with comma",
+			input: "ada@example.com,\"Lovelace, Ada\"\n",
+			want: []models.RosterRow{
+				{Line: 1, Email: "ada@example.com", Name: "Lovelace, Ada"},
+			},
+		},
+		{
+			name:  "wrong number of cells kept with an error",
+			input: "ada@example.com\nalan@example.com,Alan Turing,S-002,extra\ngrace@example.com,Grace Hopper\n",
+			want: []models.RosterRow{
+				{Line: 1, Errors: wrongFields},
+				{Line: 2, Errors: wrongFields},
+				{Line: 3, Email: "grace@example.com", Name: "Grace Hopper"},
+			},
+		},
+		{
+			name:  "blank lines skipped",
+			input: "ada@example.com,Ada Lovelace\n\nalan@example.com,Alan Turing\n",
+			want: []models.RosterRow{
+				{Line: 1, Email: "ada@example.com", Name: "Ada Lovelace"},
+				{Line: 3, Email: "alan@example.com", Name: "Alan Turing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRoster(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRosterErrors(t *testing.T) {
+	var full strings.Builder
+	full.WriteString("email,name\n")
+	for i := 0; i <= models.MaxRosterRows; i++ {
+		fmt.Fprintf(&full, "student%d@example.com,Student %d\n", i, i)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"too many rows", full.String(), errRosterTooLarge},
+		{"bare quote", "ada@example.com,Ada \"Lovelace\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseRoster(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("expected an error, got %d rows", len(rows))
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
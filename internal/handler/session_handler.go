@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/qrcode"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+const (
+	defaultQRSize = 512
+	minQRSize     = 128
+	maxQRSize     = 2048
+)
+
+type SessionHandler struct {
+	sessionService service.SessionService
+	validate       *validator.Validate
+	logger         zerolog.Logger
+}
+
+func NewSessionHandler(sessionService service.SessionService, logger zerolog.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		validate:       validator.New(),
+		logger:         logger,
+	}
+}
+
+// Open handles POST /api/v1/classes/:id/sessions
+// Teacher opens an attendance session for a class.
+func (h *SessionHandler) Open(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	// The body is optional; an empty one uses the default duration.
+	var input models.OpenSessionInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			BadRequest(c, "invalid request body")
+			return
+		}
+	}
+
+	if err := h.validate.Struct(&input); err != nil {
+		BadRequest(c, formatValidationError(err))
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	session, err := h.sessionService.OpenSession(c.Request.Context(), teacherID, classID, &input)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to open session")
+		InternalError(c)
+		return
+	}
+
+	h.logger.Info().
+		Str("class_id", classIDStr).
+		Str("session_id", session.ID.String()).
+		Msg("session opened")
+
+	Success(c, http.StatusCreated, session.ToResponse())
+}
+
+// Close handles POST /api/v1/classes/:id/sessions/:sessionId/close
+// Teacher closes a session before it expires.
+func (h *SessionHandler) Close(c *gin.Context) {
+	classID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	sessionIDStr := c.Param("sessionId")
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		BadRequest(c, "invalid session ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	session, err := h.sessionService.CloseSession(c.Request.Context(), teacherID, classID, sessionID)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("session_id", sessionIDStr).Msg("failed to close session")
+		InternalError(c)
+		return
+	}
+
+	h.logger.Info().Str("session_id", sessionIDStr).Msg("session closed")
+
+	Success(c, http.StatusOK, session.ToResponse())
+}
+
+// Current handles GET /api/v1/classes/:id/sessions/current
+// Returns the open session for a class (teacher only).
+func (h *SessionHandler) Current(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	session, err := h.sessionService.GetCurrentSession(c.Request.Context(), teacherID, classID)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to get current session")
+		InternalError(c)
+		return
+	}
+
+	Success(c, http.StatusOK, session.ToResponse())
+}
+
+// CheckinCode handles GET /api/v1/classes/:id/sessions/current/code
+// Returns the rotating check-in code for the teacher to display.
+func (h *SessionHandler) CheckinCode(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	code, err := h.sessionService.GetCheckinCode(c.Request.Context(), teacherID, classID)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to get check-in code")
+		InternalError(c)
+		return
+	}
+
+	// The code rotates every period; intermediaries must not serve a stale one.
+	c.Header("Cache-Control", "no-store")
+	Success(c, http.StatusOK, code)
+}
+
+// CheckinQR handles GET /api/v1/classes/:id/sessions/current/qr?format=png|svg&size=N
+// Renders the current check-in deep link as a QR code for projection.
+func (h *SessionHandler) CheckinQR(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "svg" {
+		BadRequest(c, "format must be one of: png svg")
+		return
+	}
+
+	size := defaultQRSize
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < minQRSize || size > maxQRSize {
+			BadRequest(c, "size must be between 128 and 2048")
+			return
+		}
+	}
+
+	teacherID := middleware.GetUserID(c)
+	code, err := h.sessionService.GetCheckinCode(c.Request.Context(), teacherID, classID)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to get check-in code")
+		InternalError(c)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+
+	if format == "svg" {
+		svg, err := qrcode.SVG(code.Link)
+		if err != nil {
+			h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to render qr svg")
+			InternalError(c)
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", []byte(svg))
+		return
+	}
+
+	png, err := qrcode.PNG(code.Link, size)
+	if err != nil {
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to render qr png")
+		InternalError(c)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// List handles GET /api/v1/classes/:id/sessions
+// Returns every session of a class, newest first (teacher only).
+func (h *SessionHandler) List(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	teacherID := middleware.GetUserID(c)
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), teacherID, classID)
+	if err != nil {
+		if h.handleError(c, err) {
+			return
+		}
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to list sessions")
+		InternalError(c)
+		return
+	}
+
+	response := make([]models.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = session.ToResponse()
+	}
+
+	Success(c, http.StatusOK, response)
+}
+
+// handleError writes the response for known session errors and reports whether it did.
+func (h *SessionHandler) handleError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		NotFound(c, "class not found")
+	case errors.Is(err, service.ErrSessionNotFound):
+		NotFound(c, "session not found")
+	case errors.Is(err, service.ErrNoOpenSession):
+		NotFound(c, "no open session for this class")
+	case errors.Is(err, service.ErrClassPermissionDenied):
+		Forbidden(c, "insufficient permissions for this class")
+	case errors.Is(err, service.ErrClassArchived):
+		Error(c, http.StatusConflict, "class is archived")
+	case errors.Is(err, service.ErrSessionAlreadyOpen):
+		Error(c, http.StatusConflict, "a session is already open for this class")
+	case errors.Is(err, service.ErrSessionClosed):
+		Error(c, http.StatusConflict, "session is already closed")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+	"github.com/tahiriqbal095/attendify/internal/service"
+	"github.com/tahiriqbal095/attendify/internal/ws"
+)
+
+type WSHandler struct {
+	hub         *ws.Hub
+	authService service.AuthService
+	revocations service.RevocationStore
+	authorizer  service.Authorizer
+	logger      zerolog.Logger
+}
+
+func NewWSHandler(
+	hub *ws.Hub,
+	authService service.AuthService,
+	revocations service.RevocationStore,
+	authorizer service.Authorizer,
+	logger zerolog.Logger,
+) *WSHandler {
+	return &WSHandler{
+		hub:         hub,
+		authService: authService,
+		revocations: revocations,
+		authorizer:  authorizer,
+		logger:      logger,
+	}
+}
+
+// Connect handles GET /api/v1/ws/classes/:id
+// Upgrades to a WebSocket subscribed to live events for a class.
+// Browsers cannot set headers on WebSocket requests, so the JWT may be
+// passed as a "token" query parameter instead of an Authorization header.
+// The socket closes when the token expires, and soon after the token is
+// revoked or the user loses access to the class.
+func (h *WSHandler) Connect(c *gin.Context) {
+	classIDStr := c.Param("id")
+	classID, err := uuid.Parse(classIDStr)
+	if err != nil {
+		BadRequest(c, "invalid class ID")
+		return
+	}
+
+	claims, err := h.authService.ValidateToken(bearerOrQueryToken(c))
+	if err != nil {
+		Unauthorized(c, "invalid or expired token")
+		return
+	}
+
+	revoked, err := h.revocations.IsRevoked(c.Request.Context(), claims)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to check token revocation")
+		InternalError(c)
+		return
+	}
+	if revoked {
+		Unauthorized(c, "invalid or expired token")
+		return
+	}
+	if !h.authService.MFASatisfied(claims) {
+		Forbidden(c, "two-factor authentication required")
+		return
+	}
+
+	// No Auth middleware runs here, so attach the actor for the authorizer.
+	ctx := requestctx.WithActor(c.Request.Context(), requestctx.Actor{UserID: claims.UserID, Role: claims.Role})
+	if _, err := h.authorizer.AuthorizeClass(ctx, claims.UserID, classID, models.PermClassView); err != nil {
+		switch {
+		case errors.Is(err, service.ErrClassNotFound):
+			NotFound(c, "class not found")
+		case errors.Is(err, service.ErrClassPermissionDenied):
+			Forbidden(c, "not a member of this class")
+		default:
+			h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to authorize class subscription")
+			InternalError(c)
+		}
+		return
+	}
+
+	// Staff who can follow attendance get the teacher feed, everyone else the student feed.
+	role := models.RoleStudent
+	staff, err := h.authorizer.HasClassPermission(ctx, claims.UserID, classID, models.PermAttendanceView)
+	if err != nil {
+		h.logger.Error().Err(err).Str("class_id", classIDStr).Msg("failed to check class permission")
+		InternalError(c)
+		return
+	}
+	if staff {
+		role = models.RoleTeacher
+	}
+
+	sub := ws.Subscriber{
+		ClassID:   classID,
+		UserID:    claims.UserID,
+		Role:      role,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Authorize: func(ctx context.Context) (bool, error) {
+			return h.stillAuthorized(ctx, claims, classID, staff)
+		},
+	}
+	if err := h.hub.Serve(c.Writer, c.Request, sub); err != nil {
+		// The upgrader has already written an HTTP error response.
+		h.logger.Warn().Err(err).Str("class_id", classIDStr).Msg("websocket upgrade failed")
+	}
+}
+
+// stillAuthorized repeats the upgrade checks for a connected subscriber.
+// Staff on the teacher feed must also keep attendance:view.
+func (h *WSHandler) stillAuthorized(
+	ctx context.Context, claims *service.Claims, classID uuid.UUID, staff bool,
+) (bool, error) {
+	revoked, err := h.revocations.IsRevoked(ctx, claims)
+	if err != nil || revoked {
+		return false, err
+	}
+
+	ctx = requestctx.WithActor(ctx, requestctx.Actor{UserID: claims.UserID, Role: claims.Role})
+	perm := models.PermClassView
+	if staff {
+		perm = models.PermAttendanceView
+	}
+
+	return h.authorizer.HasClassPermission(ctx, claims.UserID, classID, perm)
+}
+
+// bearerOrQueryToken extracts the JWT from the Authorization header or the token query parameter.
+func bearerOrQueryToken(c *gin.Context) string {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		return parts[1]
+	}
+	return c.Query("token")
+}
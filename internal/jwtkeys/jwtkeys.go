@@ -0,0 +1,235 @@
+// Package jwtkeys loads the keys used to sign and verify access tokens and
+// publishes the public half as a JSON Web Key Set (RFC 7517).
+//
+// A key set signs with exactly one key and verifies with any key it holds,
+// selected by the token's kid header, so a new signing key can be rolled out
+// while tokens signed by the previous one are still accepted.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for RS256.
+const minRSABits = 2048
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrAlgMismatch    = errors.New("token algorithm does not match key")
+	ErrUnsupportedKey = errors.New("unsupported key type; use Ed25519 or RSA")
+)
+
+// Key is one signing or verification key.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey is nil for keys that only verify.
+	signKey   interface{}
+	verifyKey interface{}
+	// jwk is the public JWK, or nil for symmetric keys, which are never published.
+	jwk *JWK
+}
+
+// KeySet signs new tokens with one key and verifies tokens against all of them.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	// order keeps the JWKS output stable.
+	order []string
+}
+
+// NewHMACKeySet signs and verifies with a shared HS256 secret. It publishes no
+// keys, so only holders of the secret can verify tokens.
+func NewHMACKeySet(secret []byte) *KeySet {
+	key := &Key{
+		ID:        "hs256",
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+	return &KeySet{
+		signing: key,
+		keys:    map[string]*Key{key.ID: key},
+		order:   []string{key.ID},
+	}
+}
+
+// LoadKeySet reads a PEM private key to sign with and any number of PEM keys
+// (public or private) that are still accepted for verification.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	data, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	signing, err := parseKey(data, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", signingKeyFile, err)
+	}
+
+	set := &KeySet{
+		signing: signing,
+		keys:    map[string]*Key{signing.ID: signing},
+		order:   []string{signing.ID},
+	}
+
+	for _, file := range verificationKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verification key: %w", err)
+		}
+		key, err := parseKey(data, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse verification key %s: %w", file, err)
+		}
+		if _, ok := set.keys[key.ID]; ok {
+			continue
+		}
+		set.keys[key.ID] = key
+		set.order = append(set.order, key.ID)
+	}
+
+	return set, nil
+}
+
+// Sign returns the compact JWT for claims, stamped with the signing key's kid.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.signKey)
+}
+
+// Keyfunc selects the verification key for a token by its kid header and
+// refuses tokens whose alg differs from that key's, preventing algorithm
+// confusion attacks.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrAlgMismatch
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys in publishing order, signing key first.
+func (s *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range s.order {
+		if jwk := s.keys[kid].jwk; jwk != nil {
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return set
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// parseKey decodes a PEM key. Signing keys must be private.
+func parseKey(data []byte, signing bool) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		if signing {
+			return nil, errors.New("signing key must be a private key")
+		}
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var signKey interface{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		if signing {
+			signKey = signer
+		}
+		parsed = signer.Public()
+	}
+
+	key := &Key{signKey: signKey, verifyKey: parsed}
+	switch pub := parsed.(type) {
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.jwk = &JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.jwk = &JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	key.ID = thumbprint(key.jwk)
+	key.jwk.Kid = key.ID
+	key.jwk.Use = "sig"
+	key.jwk.Alg = key.Method.Alg()
+
+	return key, nil
+}
+
+// thumbprint derives a stable kid from the key material (RFC 7638): the
+// SHA-256 of the required members in lexicographic order, base64url-encoded.
+func thumbprint(jwk *JWK) string {
+	var canonical string
+	switch jwk.Kty {
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+// Package mail sends transactional email through a pluggable Mailer.
+package mail
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers a message or reports why it could not.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
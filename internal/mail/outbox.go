@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// outboxSize bounds how many messages wait for delivery before Send fails.
+	outboxSize = 256
+	// deliveryTimeout caps a single delivery attempt.
+	deliveryTimeout = 30 * time.Second
+)
+
+// Outbox sends mail from a background goroutine so request latency does not
+// depend on the mail server, and never reveals through timing whether a
+// message was sent at all.
+type Outbox struct {
+	mailer Mailer
+	queue  chan Message
+	logger zerolog.Logger
+}
+
+func NewOutbox(mailer Mailer, logger zerolog.Logger) *Outbox {
+	return &Outbox{
+		mailer: mailer,
+		queue:  make(chan Message, outboxSize),
+		logger: logger,
+	}
+}
+
+// Send queues msg without waiting for delivery.
+func (o *Outbox) Send(_ context.Context, msg Message) error {
+	select {
+	case o.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run delivers queued messages until ctx is cancelled. Failures are logged, not retried.
+func (o *Outbox) Run(ctx context.Context) {
+	for {
+		select {
+		case msg := <-o.queue:
+			sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+			if err := o.mailer.Send(sendCtx, msg); err != nil {
+				o.logger.Error().Err(err).Str("subject", msg.Subject).Msg("Failed to deliver mail")
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
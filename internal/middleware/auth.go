@@ -8,15 +8,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
 	"github.com/tahiriqbal095/attendify/internal/service"
 )
 
 const (
 	ContextKeyUserID = "user_id"
 	ContextKeyRole   = "role"
+	ContextKeyClaims = "claims"
 )
 
-func Auth(authService service.AuthService) gin.HandlerFunc {
+func Auth(authService service.AuthService, revocations service.RevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -43,9 +45,27 @@ func Auth(authService service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+		if revoked {
+			abortUnauthorized(c, "invalid or expired token")
+			return
+		}
+
 		// Store user info in context for downstream handlers
 		c.Set(ContextKeyUserID, claims.UserID)
 		c.Set(ContextKeyRole, claims.Role)
+		c.Set(ContextKeyClaims, claims)
+
+		// Services read the actor from the request context for auditing.
+		actor := requestctx.Actor{UserID: claims.UserID, Role: claims.Role}
+		c.Request = c.Request.WithContext(requestctx.WithActor(c.Request.Context(), actor))
 
 		c.Next()
 	}
@@ -69,6 +89,16 @@ func GetUserRole(c *gin.Context) models.Role {
 	return ""
 }
 
+// GetClaims returns the validated token claims, or nil outside Auth.
+func GetClaims(c *gin.Context) *service.Claims {
+	if v, exists := c.Get(ContextKeyClaims); exists {
+		if claims, ok := v.(*service.Claims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
 func abortUnauthorized(c *gin.Context, message string) {
 	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 		"success": false,
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tahiriqbal095/attendify/internal/service"
+)
+
+// RequireMFA rejects tokens that do not meet the two-factor policy for their
+// role. It must run after Auth.
+func RequireMFA(authService service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || !authService.MFASatisfied(claims) {
+			abortForbidden(c, "two-factor authentication required")
+			return
+		}
+
+		c.Next()
+	}
+}
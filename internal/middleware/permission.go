@@ -7,7 +7,10 @@ import (
 	"github.com/tahiriqbal095/attendify/internal/models"
 )
 
-func RequireRole(allowedRoles ...models.Role) gin.HandlerFunc {
+// RequirePermission allows the request if the user's role grants every
+// listed permission. It only knows global roles: permissions held through a
+// role in a class are checked by the services, which know the class.
+func RequirePermission(perms ...models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole := GetUserRole(c)
 		if userRole == "" {
@@ -15,25 +18,17 @@ func RequireRole(allowedRoles ...models.Role) gin.HandlerFunc {
 			return
 		}
 
-		for _, role := range allowedRoles {
-			if userRole == role {
-				c.Next()
+		for _, perm := range perms {
+			if !userRole.Can(perm) {
+				abortForbidden(c, "insufficient permissions")
 				return
 			}
 		}
 
-		abortForbidden(c, "insufficient permissions")
+		c.Next()
 	}
 }
 
-func RequireTeacher() gin.HandlerFunc {
-	return RequireRole(models.RoleTeacher)
-}
-
-func RequireStudent() gin.HandlerFunc {
-	return RequireRole(models.RoleStudent)
-}
-
 func abortForbidden(c *gin.Context, message string) {
 	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 		"success": false,
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+)
+
+const (
+	HeaderRequestID     = "X-Request-ID"
+	ContextKeyRequestID = "request_id"
+	maxRequestIDLength  = 64
+)
+
+// RequestID tags each request with an ID, reusing a caller-supplied one when
+// it looks sane, and echoes it back so clients can quote it in bug reports.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(ContextKeyRequestID, requestID)
+		c.Request = c.Request.WithContext(requestctx.WithRequestID(c.Request.Context(), requestID))
+		c.Header(HeaderRequestID, requestID)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttendanceStatus string
+
+const (
+	StatusPresent AttendanceStatus = "present"
+	StatusLate    AttendanceStatus = "late"
+	StatusAbsent  AttendanceStatus = "absent"
+	StatusExcused AttendanceStatus = "excused"
+)
+
+func (s AttendanceStatus) IsValid() bool {
+	switch s {
+	case StatusPresent, StatusLate, StatusAbsent, StatusExcused:
+		return true
+	}
+	return false
+}
+
+type Attendance struct {
+	ID        uuid.UUID        `json:"id"`
+	ClassID   uuid.UUID        `json:"class_id"`
+	SessionID uuid.UUID        `json:"session_id"`
+	StudentID uuid.UUID        `json:"student_id"`
+	Status    AttendanceStatus `json:"status"`
+	Reason    *string          `json:"reason,omitempty"`
+	Location  Location         `json:"location"`
+	MarkedAt  time.Time        `json:"marked_at"`
+
+	// OverriddenBy and OverriddenAt record the last manual change by a teacher.
+	OverriddenBy *uuid.UUID `json:"overridden_by,omitempty"`
+	OverriddenAt *time.Time `json:"overridden_at,omitempty"`
+}
+
+// AttendanceOverride is one manual status change, kept as history.
+type AttendanceOverride struct {
+	ID             uuid.UUID         `json:"id"`
+	AttendanceID   uuid.UUID         `json:"attendance_id"`
+	PreviousStatus *AttendanceStatus `json:"previous_status,omitempty"`
+	NewStatus      AttendanceStatus  `json:"new_status"`
+	Reason         *string           `json:"reason,omitempty"`
+	ChangedBy      uuid.UUID         `json:"changed_by"`
+	ChangedAt      time.Time         `json:"changed_at"`
+}
+
+// Location is the position a student reported when marking, kept for review.
+type Location struct {
+	Latitude       *float64 `json:"latitude,omitempty"`
+	Longitude      *float64 `json:"longitude,omitempty"`
+	AccuracyMeters *float64 `json:"accuracy_meters,omitempty"`
+	// DistanceMeters is measured from the class geofence centre, when one is set.
+	DistanceMeters *float64 `json:"distance_meters,omitempty"`
+}
+
+type MarkAttendanceInput struct {
+	Code           string   `json:"code" validate:"required,len=6,numeric"`
+	Latitude       *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude      *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	AccuracyMeters *float64 `json:"accuracy_meters" validate:"omitempty,gte=0"`
+}
+
+type SetAttendanceInput struct {
+	Status AttendanceStatus `json:"status" validate:"required,oneof=present late absent excused"`
+	Reason string           `json:"reason" validate:"required_if=Status excused,max=500"`
+}
+
+type BulkAttendanceRecord struct {
+	StudentID uuid.UUID        `json:"student_id"`
+	Status    AttendanceStatus `json:"status" validate:"required,oneof=present late absent excused"`
+	Reason    string           `json:"reason" validate:"required_if=Status excused,max=500"`
+}
+
+type BulkSetAttendanceInput struct {
+	Records []BulkAttendanceRecord `json:"records" validate:"required,min=1,max=500,dive"`
+}
+
+type AttendanceResponse struct {
+	ID        uuid.UUID        `json:"id"`
+	ClassID   uuid.UUID        `json:"class_id"`
+	SessionID uuid.UUID        `json:"session_id"`
+	StudentID uuid.UUID        `json:"student_id"`
+	Status    AttendanceStatus `json:"status"`
+	Reason    *string          `json:"reason,omitempty"`
+	MarkedAt  time.Time        `json:"marked_at"`
+
+	OverriddenBy *uuid.UUID `json:"overridden_by,omitempty"`
+	OverriddenAt *time.Time `json:"overridden_at,omitempty"`
+}
+
+type StudentAttendance struct {
+	ID       uuid.UUID        `json:"id"`
+	Student  UserResponse     `json:"student"`
+	Status   AttendanceStatus `json:"status"`
+	Reason   *string          `json:"reason,omitempty"`
+	Location Location         `json:"location"`
+	MarkedAt time.Time        `json:"marked_at"`
+
+	OverriddenBy *uuid.UUID `json:"overridden_by,omitempty"`
+	OverriddenAt *time.Time `json:"overridden_at,omitempty"`
+}
+
+// GeofenceViolation is a rejected mark from outside the class geofence.
+type GeofenceViolation struct {
+	ID             uuid.UUID `json:"id"`
+	ClassID        uuid.UUID `json:"class_id"`
+	SessionID      uuid.UUID `json:"session_id"`
+	StudentID      uuid.UUID `json:"student_id"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AccuracyMeters *float64  `json:"accuracy_meters,omitempty"`
+	DistanceMeters float64   `json:"distance_meters"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+}
+
+type GeofenceViolationWithStudent struct {
+	ID             uuid.UUID    `json:"id"`
+	SessionID      uuid.UUID    `json:"session_id"`
+	Student        UserResponse `json:"student"`
+	Latitude       float64      `json:"latitude"`
+	Longitude      float64      `json:"longitude"`
+	AccuracyMeters *float64     `json:"accuracy_meters,omitempty"`
+	DistanceMeters float64      `json:"distance_meters"`
+	AttemptedAt    time.Time    `json:"attempted_at"`
+}
+
+func (a *Attendance) ToResponse() AttendanceResponse {
+	return AttendanceResponse{
+		ID:        a.ID,
+		ClassID:   a.ClassID,
+		SessionID: a.SessionID,
+		StudentID: a.StudentID,
+		Status:    a.Status,
+		Reason:    a.Reason,
+		MarkedAt:  a.MarkedAt,
+
+		OverriddenBy: a.OverriddenBy,
+		OverriddenAt: a.OverriddenAt,
+	}
+}
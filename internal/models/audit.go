@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions, named <entity>.<verb>.
+const (
+	AuditUserRegistered         = "user.registered"
+	AuditUserInvited            = "user.invited"
+	AuditEmailVerified          = "user.email_verified"
+	AuditUserRoleChanged        = "user.role_changed"
+	AuditUserDisabled           = "user.disabled"
+	AuditUserEnabled            = "user.enabled"
+	AuditPasswordResetForced    = "user.password_reset_forced"
+	AuditUserDeleted            = "user.deleted"
+	AuditUserLoggedOut          = "auth.logged_out"
+	AuditRefreshTokenReused     = "auth.refresh_token_reused"
+	AuditPasswordResetRequested = "auth.password_reset_requested"
+	AuditPasswordReset          = "auth.password_reset"
+	AuditAccountLocked          = "auth.account_locked"
+	AuditAccountUnlocked        = "auth.account_unlocked"
+	AuditMFAEnabled             = "auth.mfa_enabled"
+	AuditMFADisabled            = "auth.mfa_disabled"
+	AuditMFARecoveryCodeUsed    = "auth.mfa_recovery_code_used"
+
+	AuditClassCreated                 = "class.created"
+	AuditClassUpdated                 = "class.updated"
+	AuditClassArchived                = "class.archived"
+	AuditClassRestored                = "class.restored"
+	AuditClassDeleted                 = "class.deleted"
+	AuditGeofenceUpdated              = "class.geofence_updated"
+	AuditClassCodeRegenerated         = "class.code_regenerated"
+	AuditClassCodePolicyUpdated       = "class.code_policy_updated"
+	AuditClassEnrollmentPolicyUpdated = "class.enrollment_policy_updated"
+	AuditClassRosterImported          = "class.roster_imported"
+	AuditStaffAdded                   = "class.staff_added"
+	AuditStaffRemoved                 = "class.staff_removed"
+
+	AuditEnrollmentCreated  = "enrollment.created"
+	AuditEnrollmentApproved = "enrollment.approved"
+	AuditEnrollmentRejected = "enrollment.rejected"
+	AuditEnrollmentPromoted = "enrollment.promoted"
+	AuditEnrollmentDeleted  = "enrollment.deleted"
+
+	AuditInvitationCreated  = "invitation.created"
+	AuditInvitationRevoked  = "invitation.revoked"
+	AuditInvitationAccepted = "invitation.accepted"
+
+	AuditSessionOpened  = "session.opened"
+	AuditSessionClosed  = "session.closed"
+	AuditSessionExpired = "session.expired"
+
+	AuditAttendanceMarked   = "attendance.marked"
+	AuditAttendanceOverride = "attendance.overridden"
+)
+
+// Audited entity types.
+const (
+	EntityUser       = "user"
+	EntityClass      = "class"
+	EntityEnrollment = "enrollment"
+	EntityInvitation = "invitation"
+	EntitySession    = "session"
+	EntityAttendance = "attendance"
+)
+
+// AuditEvent is an append-only record of a state change.
+// ActorID is nil for changes made by background jobs.
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	ActorRole  *Role           `json:"actor_role"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   *uuid.UUID      `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  *string         `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditEventFilter narrows an audit log query. Zero values match everything.
+type AuditEventFilter struct {
+	ActorID    *uuid.UUID
+	EntityType string
+	EntityID   *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditEventPage struct {
+	Events []AuditEvent `json:"events"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	// NextOffset is set when more events may follow.
+	NextOffset *int `json:"next_offset,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type RegisterInput struct {
 	Email    string `json:"email" validate:"required,email,max=255"`
 	Password string `json:"password" validate:"required,min=8,max=72"`
@@ -12,7 +14,22 @@ type LoginInput struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
+}
+
+type LogoutInput struct {
+	// RefreshToken, when given, is revoked along with the rest of its family.
+	RefreshToken string `json:"refresh_token" validate:"omitempty,max=128"`
+}
+
+// AuthResponse carries either tokens or, when the account has two-factor
+// authentication, an MFAChallenge to complete at /auth/mfa/verify.
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token            string                `json:"token,omitempty"`
+	ExpiresAt        *time.Time            `json:"expires_at,omitempty"`
+	RefreshToken     string                `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time            `json:"refresh_expires_at,omitempty"`
+	MFAChallenge     *MFAChallengeResponse `json:"mfa_challenge,omitempty"`
+	User             UserResponse          `json:"user"`
 }
@@ -7,31 +7,105 @@ import (
 )
 
 type Class struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Code      string    `json:"code"`
-	TeacherID uuid.UUID `json:"teacher_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID        `json:"id"`
+	Name       string           `json:"name"`
+	Code       string           `json:"code"`
+	TeacherID  uuid.UUID        `json:"teacher_id"`
+	Geofence   Geofence         `json:"geofence"`
+	CodePolicy CodePolicy       `json:"code_policy"`
+	Enrollment EnrollmentPolicy `json:"enrollment_policy"`
+	ArchivedAt *time.Time       `json:"archived_at,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// IsArchived reports whether the class is closed to new enrollments and sessions.
+func (c *Class) IsArchived() bool {
+	return c.ArchivedAt != nil
+}
+
+// Geofence restricts attendance marking to a circle around the classroom.
+type Geofence struct {
+	Enabled      bool     `json:"enabled"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	RadiusMeters *int     `json:"radius_meters,omitempty"`
+}
+
+// CodePolicy controls who may join a class with its code.
+type CodePolicy struct {
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	// Uses counts students who joined with the code since it was last regenerated.
+	// Requests awaiting approval never count; the teacher admits them.
+	Uses int `json:"uses"`
+}
+
+// IsExpired reports whether the code has passed its expiry date.
+func (p *CodePolicy) IsExpired(now time.Time) bool {
+	return p.ExpiresAt != nil && !now.Before(*p.ExpiresAt)
+}
+
+// IsExhausted reports whether the code has been used as often as allowed.
+func (p *CodePolicy) IsExhausted() bool {
+	return p.MaxUses != nil && p.Uses >= *p.MaxUses
 }
 
 type CreateClassInput struct {
 	Name string `json:"name" validate:"required,min=2,max=100"`
 }
 
+// UpdateClassInput changes class metadata; omitted fields keep their stored values.
+type UpdateClassInput struct {
+	Name *string `json:"name" validate:"omitempty,min=2,max=100"`
+}
+
+// UpdateGeofenceInput toggles the geofence; omitted fields keep their stored values.
+type UpdateGeofenceInput struct {
+	Enabled      *bool    `json:"enabled"`
+	Latitude     *float64 `json:"latitude" validate:"omitempty,gte=-90,lte=90"`
+	Longitude    *float64 `json:"longitude" validate:"omitempty,gte=-180,lte=180"`
+	RadiusMeters *int     `json:"radius_meters" validate:"omitempty,gte=10,lte=5000"`
+}
+
+// UpdateCodePolicyInput replaces the code policy. Omitting expires_at or
+// max_uses removes that limit.
+type UpdateCodePolicyInput struct {
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   *int       `json:"max_uses" validate:"omitempty,gte=1"`
+}
+
 type ClassResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Code      string    `json:"code"`
-	TeacherID uuid.UUID `json:"teacher_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID         `json:"id"`
+	Name       string            `json:"name"`
+	Code       string            `json:"code"`
+	TeacherID  uuid.UUID         `json:"teacher_id"`
+	Geofence   *Geofence         `json:"geofence,omitempty"`
+	CodePolicy *CodePolicy       `json:"code_policy,omitempty"`
+	Enrollment *EnrollmentPolicy `json:"enrollment_policy,omitempty"`
+	ArchivedAt *time.Time        `json:"archived_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
 }
 
 func (c *Class) ToResponse() ClassResponse {
-	return ClassResponse{
-		ID:        c.ID,
-		Name:      c.Name,
-		Code:      c.Code,
-		TeacherID: c.TeacherID,
-		CreatedAt: c.CreatedAt,
+	codePolicy := c.CodePolicy
+	enrollment := c.Enrollment
+	response := ClassResponse{
+		ID:         c.ID,
+		Name:       c.Name,
+		Code:       c.Code,
+		TeacherID:  c.TeacherID,
+		CodePolicy: &codePolicy,
+		Enrollment: &enrollment,
+		ArchivedAt: c.ArchivedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+
+	if c.Geofence.Latitude != nil {
+		geofence := c.Geofence
+		response.Geofence = &geofence
 	}
+
+	return response
 }
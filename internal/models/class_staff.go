@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClassStaff assigns a user a staff role in a class.
+type ClassStaff struct {
+	ClassID   uuid.UUID `json:"class_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      ClassRole `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StaffMember is a class staff assignment with the user's details.
+type StaffMember struct {
+	User    UserResponse `json:"user"`
+	Role    ClassRole    `json:"role"`
+	AddedAt time.Time    `json:"added_at"`
+}
+
+// AddStaffInput adds an existing account to a class's staff. Every class has
+// exactly one owner, so it cannot be granted this way.
+type AddStaffInput struct {
+	Email string    `json:"email" validate:"required,email"`
+	Role  ClassRole `json:"role" validate:"required,oneof=co_teacher assistant"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationToken proves ownership of a user's email address.
+// Only the SHA-256 hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
@@ -6,10 +6,32 @@ import (
 	"github.com/google/uuid"
 )
 
+// EnrollmentStatus is where an enrollment stands. Only active enrollments
+// count as being in the class.
+type EnrollmentStatus string
+
+const (
+	EnrollmentActive     EnrollmentStatus = "active"
+	EnrollmentPending    EnrollmentStatus = "pending"
+	EnrollmentWaitlisted EnrollmentStatus = "waitlisted"
+)
+
+// IsValid reports whether s is a known enrollment status.
+func (s EnrollmentStatus) IsValid() bool {
+	switch s {
+	case EnrollmentActive, EnrollmentPending, EnrollmentWaitlisted:
+		return true
+	}
+	return false
+}
+
 type Enrollment struct {
-	ID         uuid.UUID `json:"id"`
-	ClassID    uuid.UUID `json:"class_id"`
-	StudentID  uuid.UUID `json:"student_id"`
+	ID        uuid.UUID        `json:"id"`
+	ClassID   uuid.UUID        `json:"class_id"`
+	StudentID uuid.UUID        `json:"student_id"`
+	Status    EnrollmentStatus `json:"status"`
+	// EnrolledAt is when the enrollment entered its current status, which
+	// orders the waitlist.
 	EnrolledAt time.Time `json:"enrolled_at"`
 }
 
@@ -18,10 +40,11 @@ type EnrollByCodeInput struct {
 }
 
 type EnrollmentResponse struct {
-	ID         uuid.UUID `json:"id"`
-	ClassID    uuid.UUID `json:"class_id"`
-	StudentID  uuid.UUID `json:"student_id"`
-	EnrolledAt time.Time `json:"enrolled_at"`
+	ID         uuid.UUID        `json:"id"`
+	ClassID    uuid.UUID        `json:"class_id"`
+	StudentID  uuid.UUID        `json:"student_id"`
+	Status     EnrollmentStatus `json:"status"`
+	EnrolledAt time.Time        `json:"enrolled_at"`
 }
 
 type EnrollmentWithClass struct {
@@ -36,11 +59,51 @@ type StudentInClass struct {
 	EnrolledAt time.Time    `json:"enrolled_at"`
 }
 
+// EnrollmentRequest is a pending or waitlisted enrollment as teachers see it.
+type EnrollmentRequest struct {
+	ID          uuid.UUID        `json:"id"`
+	Student     UserResponse     `json:"student"`
+	Status      EnrollmentStatus `json:"status"`
+	RequestedAt time.Time        `json:"requested_at"`
+	// Position is the 1-based place on the waitlist.
+	Position *int `json:"position,omitempty"`
+}
+
+// MyEnrollmentRequest is a pending or waitlisted enrollment as the student sees it.
+type MyEnrollmentRequest struct {
+	ID          uuid.UUID        `json:"id"`
+	Class       ClassResponse    `json:"class"`
+	Status      EnrollmentStatus `json:"status"`
+	RequestedAt time.Time        `json:"requested_at"`
+	Position    *int             `json:"position,omitempty"`
+}
+
+// EnrollmentPolicy controls how students get into a class.
+type EnrollmentPolicy struct {
+	// RequiresApproval holds new enrollments as pending until a teacher approves them.
+	RequiresApproval bool `json:"requires_approval"`
+	// Capacity caps active enrollments; students beyond it are waitlisted.
+	Capacity *int `json:"capacity,omitempty"`
+}
+
+// HasRoom reports whether another student fits next to active ones.
+func (p *EnrollmentPolicy) HasRoom(active int) bool {
+	return p.Capacity == nil || active < *p.Capacity
+}
+
+// UpdateEnrollmentPolicyInput replaces the enrollment policy. Omitting
+// capacity removes the limit.
+type UpdateEnrollmentPolicyInput struct {
+	RequiresApproval bool `json:"requires_approval"`
+	Capacity         *int `json:"capacity" validate:"omitempty,gte=1,lte=10000"`
+}
+
 func (e *Enrollment) ToResponse() EnrollmentResponse {
 	return EnrollmentResponse{
 		ID:         e.ID,
 		ClassID:    e.ClassID,
 		StudentID:  e.StudentID,
+		Status:     e.Status,
 		EnrolledAt: e.EnrolledAt,
 	}
 }
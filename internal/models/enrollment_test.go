@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestEnrollmentPolicyHasRoom(t *testing.T) {
+	capacity := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		capacity *int
+		active   int
+		want     bool
+	}{
+		{"unlimited and empty", nil, 0, true},
+		{"unlimited and busy", nil, 10000, true},
+		{"empty", capacity(3), 0, true},
+		{"one seat left", capacity(3), 2, true},
+		{"full", capacity(3), 3, false},
+		{"over capacity after it was lowered", capacity(3), 5, false},
+		{"single seat taken", capacity(1), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := EnrollmentPolicy{Capacity: tt.capacity}
+			if got := policy.HasRoom(tt.active); got != tt.want {
+				t.Errorf("HasRoom(%d) = %v, want %v", tt.active, got, tt.want)
+			}
+		})
+	}
+}
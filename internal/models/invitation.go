@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation is a single-use emailed invitation to join a class.
+// Only the SHA-256 hash of the token is stored.
+type Invitation struct {
+	ID         uuid.UUID  `json:"id"`
+	ClassID    uuid.UUID  `json:"class_id"`
+	Email      string     `json:"email"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  *uuid.UUID `json:"invited_by"` // Nil once the inviter's account is deleted
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID `json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsOutstanding reports whether the invitation can still be accepted.
+func (i *Invitation) IsOutstanding(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+type CreateInvitationsInput struct {
+	Emails []string `json:"emails" validate:"required,min=1,max=100,dive,required,email,max=255"`
+}
+
+// AcceptInvitationInput accepts an invitation as the signed-in student.
+type AcceptInvitationInput struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+// AcceptInvitationWithSignupInput accepts an invitation by creating a
+// student account for the invited address.
+type AcceptInvitationWithSignupInput struct {
+	Token    string `json:"token" validate:"required,max=128"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// InvitationSignupResponse is the account and enrollment created by
+// accepting an invitation with a signup. The student signs in as usual.
+type InvitationSignupResponse struct {
+	User       UserResponse `json:"user"`
+	Enrollment *Enrollment  `json:"enrollment"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottle counts recent failed sign-ins for one account or client IP.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is when the next attempt is allowed, after a backoff or lockout.
+	BlockedUntil *time.Time
+}
+
+// IsBlocked reports whether attempts are refused at now.
+func (t *LoginThrottle) IsBlocked(now time.Time) bool {
+	return t.BlockedUntil != nil && now.Before(*t.BlockedUntil)
+}
+
+// AccountUnlockToken is a single-use credential emailed when an account is
+// locked. Only the SHA-256 hash of the token is stored.
+type AccountUnlockToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type UnlockAccountInput struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
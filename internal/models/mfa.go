@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA is a user's TOTP enrollment. It only protects logins once confirmed.
+type UserMFA struct {
+	UserID      uuid.UUID
+	Secret      []byte
+	ConfirmedAt *time.Time
+	// LastUsedStep is the newest TOTP time step accepted, so a code cannot be replayed.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (m *UserMFA) IsConfirmed() bool {
+	return m.ConfirmedAt != nil
+}
+
+// MFAChallenge is the pending second step of a login.
+type MFAChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	Attempts  int
+	UsedAt    *time.Time
+}
+
+type MFAEnrollmentResponse struct {
+	// Secret is base32 for manual entry into an authenticator app.
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmMFAInput struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type MFAConfirmResponse struct {
+	// RecoveryCodes are shown once; each works a single time in place of a code.
+	RecoveryCodes []string     `json:"recovery_codes"`
+	Tokens        AuthResponse `json:"tokens"`
+}
+
+// VerifyMFAInput completes a login with either a TOTP code or a recovery code.
+type VerifyMFAInput struct {
+	MFAToken     string `json:"mfa_token" validate:"required,max=128"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+type DisableMFAInput struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFAChallengeResponse is returned by Login instead of tokens when a second factor is needed.
+type MFAChallengeResponse struct {
+	MFAToken  string    `json:"mfa_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
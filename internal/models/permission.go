@@ -0,0 +1,83 @@
+package models
+
+import "slices"
+
+// Permission names one action. Global permissions come from the user's Role;
+// class permissions come from their ClassRole in that class.
+type Permission string
+
+// Global permissions.
+const (
+	PermClassCreate    Permission = "class:create"
+	PermClassJoin      Permission = "class:join"
+	PermAttendanceMark Permission = "attendance:mark"
+	PermUserManage     Permission = "user:manage"
+	PermAuditView      Permission = "audit:view"
+)
+
+// Class permissions.
+const (
+	PermClassView          Permission = "class:view"
+	PermClassUpdate        Permission = "class:update"
+	PermClassArchive       Permission = "class:archive"
+	PermClassDelete        Permission = "class:delete"
+	PermRosterView         Permission = "roster:view"
+	PermRosterManage       Permission = "roster:manage"
+	PermStaffManage        Permission = "staff:manage"
+	PermSessionManage      Permission = "session:manage"
+	PermAttendanceView     Permission = "attendance:view"
+	PermAttendanceOverride Permission = "attendance:override"
+)
+
+// ClassRole is a user's role within one class.
+type ClassRole string
+
+const (
+	ClassRoleOwner     ClassRole = "owner"
+	ClassRoleCoTeacher ClassRole = "co_teacher"
+	ClassRoleAssistant ClassRole = "assistant"
+	// ClassRoleStudent is implied by an enrollment rather than assigned.
+	ClassRoleStudent ClassRole = "student"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleTeacher: {PermClassCreate},
+	RoleStudent: {PermClassJoin, PermAttendanceMark},
+	// Admins hold every class permission in every class, and are the only
+	// ones who can delete a class with its history.
+	RoleAdmin: {
+		PermClassCreate, PermUserManage, PermAuditView,
+		PermClassView, PermClassUpdate, PermClassArchive, PermClassDelete, PermRosterView, PermRosterManage,
+		PermStaffManage, PermSessionManage, PermAttendanceView, PermAttendanceOverride,
+	},
+}
+
+var classRolePermissions = map[ClassRole][]Permission{
+	ClassRoleOwner: {
+		PermClassView, PermClassUpdate, PermClassArchive, PermRosterView, PermRosterManage,
+		PermStaffManage, PermSessionManage, PermAttendanceView, PermAttendanceOverride,
+	},
+	ClassRoleCoTeacher: {
+		PermClassView, PermClassUpdate, PermRosterView, PermRosterManage,
+		PermSessionManage, PermAttendanceView, PermAttendanceOverride,
+	},
+	ClassRoleAssistant: {
+		PermClassView, PermRosterView, PermSessionManage, PermAttendanceView, PermAttendanceOverride,
+	},
+	ClassRoleStudent: {PermClassView, PermAttendanceMark},
+}
+
+// Can reports whether the role grants a global permission.
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
+
+// Can reports whether the class role grants a permission in its class.
+func (r ClassRole) Can(p Permission) bool {
+	return slices.Contains(classRolePermissions[r], p)
+}
+
+// IsStaff reports whether the role can be assigned through class_staff.
+func (r ClassRole) IsStaff() bool {
+	return r == ClassRoleOwner || r == ClassRoleCoTeacher || r == ClassRoleAssistant
+}
@@ -0,0 +1,92 @@
+package models
+
+import "testing"
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleTeacher, PermClassCreate, true},
+		{RoleTeacher, PermClassJoin, false},
+		// Teachers hold class permissions only through their classes.
+		{RoleTeacher, PermClassView, false},
+		{RoleTeacher, PermUserManage, false},
+		{RoleStudent, PermClassJoin, true},
+		{RoleStudent, PermAttendanceMark, true},
+		{RoleStudent, PermClassCreate, false},
+		{RoleAdmin, PermUserManage, true},
+		{RoleAdmin, PermAuditView, true},
+		{RoleAdmin, PermClassDelete, true},
+		{RoleAdmin, PermAttendanceOverride, true},
+		{RoleAdmin, PermClassJoin, false},
+		{Role("unknown"), PermClassView, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.perm), func(t *testing.T) {
+			if got := tt.role.Can(tt.perm); got != tt.want {
+				t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassRoleCan(t *testing.T) {
+	tests := []struct {
+		role ClassRole
+		perm Permission
+		want bool
+	}{
+		{ClassRoleOwner, PermClassUpdate, true},
+		{ClassRoleOwner, PermClassArchive, true},
+		{ClassRoleOwner, PermStaffManage, true},
+		{ClassRoleOwner, PermAttendanceOverride, true},
+		// Only admins delete a class with its history.
+		{ClassRoleOwner, PermClassDelete, false},
+		{ClassRoleCoTeacher, PermClassUpdate, true},
+		{ClassRoleCoTeacher, PermRosterManage, true},
+		{ClassRoleCoTeacher, PermClassArchive, false},
+		{ClassRoleCoTeacher, PermStaffManage, false},
+		{ClassRoleAssistant, PermSessionManage, true},
+		{ClassRoleAssistant, PermAttendanceOverride, true},
+		{ClassRoleAssistant, PermRosterView, true},
+		{ClassRoleAssistant, PermRosterManage, false},
+		{ClassRoleAssistant, PermClassUpdate, false},
+		{ClassRoleStudent, PermClassView, true},
+		{ClassRoleStudent, PermAttendanceMark, true},
+		{ClassRoleStudent, PermAttendanceView, false},
+		{ClassRoleStudent, PermRosterView, false},
+		{ClassRole(""), PermClassView, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.perm), func(t *testing.T) {
+			if got := tt.role.Can(tt.perm); got != tt.want {
+				t.Errorf("%q.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassRoleIsStaff(t *testing.T) {
+	tests := []struct {
+		role ClassRole
+		want bool
+	}{
+		{ClassRoleOwner, true},
+		{ClassRoleCoTeacher, true},
+		{ClassRoleAssistant, true},
+		{ClassRoleStudent, false},
+		{ClassRole(""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if got := tt.role.IsStaff(); got != tt.want {
+				t.Errorf("%q.IsStaff() = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is one link in a rotation chain. Every token issued from the
+// same login shares a FamilyID so a replayed token can revoke the whole chain.
+// Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	FamilyID   uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	UsedAt     *time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID
+	// MFA records that the login passed a second factor.
+	MFA bool
+}
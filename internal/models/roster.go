@@ -0,0 +1,45 @@
+package models
+
+// MaxRosterRows bounds a single roster import.
+const MaxRosterRows = 1000
+
+// RosterAction is what importing a roster row does.
+type RosterAction string
+
+const (
+	// RosterCreate creates a student account and enrolls it.
+	RosterCreate RosterAction = "create"
+	RosterEnroll RosterAction = "enroll"
+	// RosterActivate admits a student whose enrollment is pending or waitlisted.
+	RosterActivate RosterAction = "activate"
+	// RosterSkip leaves an already enrolled student alone.
+	RosterSkip RosterAction = "skip"
+)
+
+// RosterRow is one student line of an imported roster CSV.
+type RosterRow struct {
+	// Line is the row's line number in the file, for error reports.
+	Line          int          `json:"line"`
+	Email         string       `json:"email" validate:"required,email,max=255"`
+	Name          string       `json:"name" validate:"required,min=2,max=100"`
+	StudentNumber string       `json:"student_number,omitempty" validate:"max=50"`
+	Action        RosterAction `json:"action,omitempty"`
+	Errors        []string     `json:"errors,omitempty"`
+}
+
+// RosterImportResult reports every row of an import. Nothing is written
+// for a dry run or when any row has errors.
+type RosterImportResult struct {
+	DryRun    bool        `json:"dry_run"`
+	Valid     bool        `json:"valid"`
+	Rows      []RosterRow `json:"rows"`
+	Created   int         `json:"created"`
+	Enrolled  int         `json:"enrolled"`
+	Activated int         `json:"activated"`
+	Skipped   int         `json:"skipped"`
+}
+
+// Admitted is how many rows take a seat in the class.
+func (r *RosterImportResult) Admitted() int {
+	return r.Created + r.Enrolled + r.Activated
+}
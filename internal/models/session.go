@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionStatus string
+
+const (
+	SessionOpen   SessionStatus = "open"
+	SessionClosed SessionStatus = "closed"
+)
+
+type ClassSession struct {
+	ID        uuid.UUID  `json:"id"`
+	ClassID   uuid.UUID  `json:"class_id"`
+	OpenedBy  *uuid.UUID `json:"opened_by"` // Nil once that user is deleted
+	OpenedAt  time.Time  `json:"opened_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	LateAt    *time.Time `json:"late_at"` // Marks after this count as late; nil means never
+	Secret    []byte     `json:"-"`       // Seeds rotating check-in codes; never expose
+}
+
+// IsOpen reports whether students may still mark attendance at the given time.
+func (s *ClassSession) IsOpen(now time.Time) bool {
+	return s.ClosedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type OpenSessionInput struct {
+	// DurationMinutes overrides the configured default session length.
+	DurationMinutes int `json:"duration_minutes" validate:"omitempty,min=1,max=480"`
+	// LateAfterMinutes overrides the configured late threshold.
+	LateAfterMinutes *int `json:"late_after_minutes" validate:"omitempty,min=0,max=480"`
+}
+
+type SessionResponse struct {
+	ID        uuid.UUID     `json:"id"`
+	ClassID   uuid.UUID     `json:"class_id"`
+	OpenedBy  *uuid.UUID    `json:"opened_by"`
+	OpenedAt  time.Time     `json:"opened_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	ClosedAt  *time.Time    `json:"closed_at,omitempty"`
+	LateAt    *time.Time    `json:"late_at,omitempty"`
+	Status    SessionStatus `json:"status"`
+}
+
+func (s *ClassSession) ToResponse() SessionResponse {
+	status := SessionClosed
+	if s.IsOpen(time.Now()) {
+		status = SessionOpen
+	}
+
+	return SessionResponse{
+		ID:        s.ID,
+		ClassID:   s.ClassID,
+		OpenedBy:  s.OpenedBy,
+		OpenedAt:  s.OpenedAt,
+		ExpiresAt: s.ExpiresAt,
+		ClosedAt:  s.ClosedAt,
+		LateAt:    s.LateAt,
+		Status:    status,
+	}
+}
+
+// CheckinCodeResponse is the rotating code a teacher displays for students to submit.
+type CheckinCodeResponse struct {
+	SessionID     uuid.UUID `json:"session_id"`
+	Code          string    `json:"code"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	PeriodSeconds int       `json:"period_seconds"`
+	// Link is the check-in deep link encoded in the session QR code.
+	Link string `json:"link"`
+}
+
+// CheckinCodeEvent is pushed to the teacher's screen on every code rotation.
+type CheckinCodeEvent struct {
+	CheckinCodeResponse
+	QRSVG string `json:"qr_svg"`
+}
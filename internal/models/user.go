@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,36 +12,86 @@ type Role string
 const (
 	RoleTeacher Role = "teacher"
 	RoleStudent Role = "student"
+	RoleAdmin   Role = "admin"
 )
 
 func (r Role) IsValid() bool {
-	return r == RoleTeacher || r == RoleStudent
+	return r == RoleTeacher || r == RoleStudent || r == RoleAdmin
+}
+
+// NormalizeEmail is the form emails are stored and compared in.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"` // Never expose in JSON
-	Name         string    `json:"name"`
-	Role         Role      `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	PasswordHash          string     `json:"-"` // Never expose in JSON
+	Name                  string     `json:"name"`
+	Role                  Role       `json:"role"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at"`
+	DisabledAt            *time.Time `json:"disabled_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"` // Set by an admin; blocks sign-in
+	StudentNumber         *string    `json:"student_number"`          // From roster imports
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+// IsDisabled reports whether an admin has disabled the account.
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsEmailVerified reports whether the user has proven they own their email address.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Role      Role      `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	Name                  string     `json:"name"`
+	Role                  Role       `json:"role"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at,omitempty"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required,omitempty"`
+	StudentNumber         *string    `json:"student_number,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
 }
 
 // ToResponse converts User to UserResponse for safe API output.
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		Name:      u.Name,
-		Role:      u.Role,
-		CreatedAt: u.CreatedAt,
+		ID:                    u.ID,
+		Email:                 u.Email,
+		Name:                  u.Name,
+		Role:                  u.Role,
+		EmailVerifiedAt:       u.EmailVerifiedAt,
+		DisabledAt:            u.DisabledAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		StudentNumber:         u.StudentNumber,
+		CreatedAt:             u.CreatedAt,
 	}
 }
+
+// UserFilter narrows an admin user search. Zero values match everything.
+type UserFilter struct {
+	// Query matches a substring of the email or name, ignoring case.
+	Query    string
+	Role     Role
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+type UserPage struct {
+	Users  []UserResponse `json:"users"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+	// NextOffset is set when more users may follow.
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+type ChangeRoleInput struct {
+	Role Role `json:"role" validate:"required,oneof=teacher student admin"`
+}
@@ -0,0 +1,64 @@
+// Package qrcode renders QR codes as PNG or SVG entirely in-process.
+package qrcode
+
+import (
+	"fmt"
+	"strings"
+
+	qr "github.com/skip2/go-qrcode"
+)
+
+// recoveryLevel tolerates roughly 15% damage, enough for a projector
+// with glare while keeping modules large for phones at the back of the room.
+const recoveryLevel = qr.Medium
+
+// PNG encodes content as a square PNG of the given pixel size.
+func PNG(content string, size int) ([]byte, error) {
+	code, err := qr.New(content, recoveryLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	png, err := code.PNG(size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render qr png: %w", err)
+	}
+
+	return png, nil
+}
+
+// SVG encodes content as a scalable SVG document.
+// Each module is one user unit, so the image scales cleanly to any size.
+func SVG(content string) (string, error) {
+	code, err := qr.New(content, recoveryLevel)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	bitmap := code.Bitmap()
+	size := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+
+	// Merge horizontal runs of dark modules into one rectangle each to keep the document small.
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	b.WriteString(`"/></svg>`)
+	return b.String(), nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type AccountUnlockRepository interface {
+	Create(ctx context.Context, token *models.AccountUnlockToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*models.AccountUnlockToken, error)
+	// MarkUsedByUserID consumes every outstanding token of a user.
+	MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error
+}
+
+type accountUnlockRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAccountUnlockRepository(pool *pgxpool.Pool) AccountUnlockRepository {
+	return &accountUnlockRepository{pool: pool}
+}
+
+func (r *accountUnlockRepository) Create(ctx context.Context, token *models.AccountUnlockToken) error {
+	query := `
+		INSERT INTO account_unlock_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create unlock token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate loads a token and locks it so it can only be redeemed once.
+// Must run inside a transaction.
+func (r *accountUnlockRepository) GetByHashForUpdate(
+	ctx context.Context, tokenHash string,
+) (*models.AccountUnlockToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM account_unlock_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	t := &models.AccountUnlockToken{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreatedAt,
+		&t.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get unlock token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *accountUnlockRepository) MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE account_unlock_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, userID, usedAt); err != nil {
+		return fmt.Errorf("failed to consume unlock tokens: %w", err)
+	}
+
+	return nil
+}
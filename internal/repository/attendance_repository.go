@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type AttendanceRepository interface {
+	Create(ctx context.Context, attendance *models.Attendance) error
+	Override(ctx context.Context, attendance *models.Attendance) (*models.AttendanceStatus, error)
+	CreateOverride(ctx context.Context, override *models.AttendanceOverride) error
+	CreateAbsent(ctx context.Context, sessionIDs []uuid.UUID) (int64, error)
+	GetByStudentID(ctx context.Context, classID, studentID uuid.UUID) ([]models.Attendance, error)
+	GetStudentsWithDetailsBySessionID(ctx context.Context, sessionID uuid.UUID) ([]models.StudentAttendance, error)
+	CreateGeofenceViolation(ctx context.Context, violation *models.GeofenceViolation) error
+	GetGeofenceViolationsByClassID(ctx context.Context, classID uuid.UUID) ([]models.GeofenceViolationWithStudent, error)
+}
+
+type attendanceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAttendanceRepository(pool *pgxpool.Pool) AttendanceRepository {
+	return &attendanceRepository{pool: pool}
+}
+
+func (r *attendanceRepository) Create(ctx context.Context, attendance *models.Attendance) error {
+	query := `
+		INSERT INTO attendance (
+			id, class_id, session_id, student_id, status, reason, marked_at,
+			latitude, longitude, accuracy_m, distance_m
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		attendance.ID,
+		attendance.ClassID,
+		attendance.SessionID,
+		attendance.StudentID,
+		attendance.Status,
+		attendance.Reason,
+		attendance.MarkedAt,
+		attendance.Location.Latitude,
+		attendance.Location.Longitude,
+		attendance.Location.AccuracyMeters,
+		attendance.Location.DistanceMeters,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateKey
+		}
+		return fmt.Errorf("failed to create attendance: %w", err)
+	}
+
+	return nil
+}
+
+// Override sets the status of a student's record for a session, creating the
+// record if the student has none yet, and stamps who changed it. On return,
+// ID and MarkedAt reflect the stored row. It returns the status the row had
+// before, or nil if it was created.
+func (r *attendanceRepository) Override(
+	ctx context.Context, attendance *models.Attendance,
+) (*models.AttendanceStatus, error) {
+	query := `
+		WITH previous AS (
+			SELECT status FROM attendance
+			WHERE session_id = $3 AND student_id = $4
+			FOR UPDATE
+		)
+		INSERT INTO attendance (
+			id, class_id, session_id, student_id, status, reason, marked_at,
+			overridden_by, overridden_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (session_id, student_id)
+		DO UPDATE SET
+			status = EXCLUDED.status,
+			reason = EXCLUDED.reason,
+			overridden_by = EXCLUDED.overridden_by,
+			overridden_at = EXCLUDED.overridden_at
+		RETURNING id, marked_at, (SELECT status FROM previous)
+	`
+
+	var previous *models.AttendanceStatus
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		attendance.ID,
+		attendance.ClassID,
+		attendance.SessionID,
+		attendance.StudentID,
+		attendance.Status,
+		attendance.Reason,
+		attendance.MarkedAt,
+		attendance.OverriddenBy,
+		attendance.OverriddenAt,
+	).Scan(&attendance.ID, &attendance.MarkedAt, &previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to override attendance: %w", err)
+	}
+
+	return previous, nil
+}
+
+func (r *attendanceRepository) CreateOverride(ctx context.Context, override *models.AttendanceOverride) error {
+	query := `
+		INSERT INTO attendance_overrides (
+			id, attendance_id, previous_status, new_status, reason, changed_by, changed_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		override.ID,
+		override.AttendanceID,
+		override.PreviousStatus,
+		override.NewStatus,
+		override.Reason,
+		override.ChangedBy,
+		override.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create attendance override: %w", err)
+	}
+
+	return nil
+}
+
+// CreateAbsent marks every actively enrolled student without a record in
+// the given closed sessions absent as of the session's close, in one statement.
+func (r *attendanceRepository) CreateAbsent(ctx context.Context, sessionIDs []uuid.UUID) (int64, error) {
+	query := `
+		INSERT INTO attendance (id, class_id, session_id, student_id, status, marked_at)
+		SELECT gen_random_uuid(), s.class_id, s.id, e.student_id, 'absent', s.closed_at
+		FROM class_sessions s
+		JOIN enrollments e ON e.class_id = s.class_id AND e.status = 'active'
+		WHERE s.id = ANY($1) AND s.closed_at IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM attendance a WHERE a.session_id = s.id AND a.student_id = e.student_id
+			)
+		ON CONFLICT (session_id, student_id) DO NOTHING
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, sessionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create absent records: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetByStudentID returns a student's attendance history for a class, newest first.
+func (r *attendanceRepository) GetByStudentID(
+	ctx context.Context, classID, studentID uuid.UUID,
+) ([]models.Attendance, error) {
+	query := `
+		SELECT id, class_id, session_id, student_id, status, reason, marked_at,
+			latitude, longitude, accuracy_m, distance_m, overridden_by, overridden_at
+		FROM attendance
+		WHERE class_id = $1 AND student_id = $2
+		ORDER BY marked_at DESC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attendance: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.Attendance
+	for rows.Next() {
+		var a models.Attendance
+		if err := rows.Scan(
+			&a.ID,
+			&a.ClassID,
+			&a.SessionID,
+			&a.StudentID,
+			&a.Status,
+			&a.Reason,
+			&a.MarkedAt,
+			&a.Location.Latitude,
+			&a.Location.Longitude,
+			&a.Location.AccuracyMeters,
+			&a.Location.DistanceMeters,
+			&a.OverriddenBy,
+			&a.OverriddenAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan attendance: %w", err)
+		}
+		records = append(records, a)
+	}
+
+	return records, rows.Err()
+}
+
+// GetStudentsWithDetailsBySessionID returns the students who attended a session with full user details.
+func (r *attendanceRepository) GetStudentsWithDetailsBySessionID(
+	ctx context.Context, sessionID uuid.UUID,
+) ([]models.StudentAttendance, error) {
+	query := `
+		SELECT a.id, a.status, a.reason, a.marked_at, a.latitude, a.longitude, a.accuracy_m, a.distance_m,
+			a.overridden_by, a.overridden_at, u.id, u.email, u.name, u.role, u.created_at
+		FROM attendance a
+		JOIN users u ON a.student_id = u.id
+		WHERE a.session_id = $1
+		ORDER BY u.name ASC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attendance for session: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.StudentAttendance
+	for rows.Next() {
+		var sa models.StudentAttendance
+		if err := rows.Scan(
+			&sa.ID,
+			&sa.Status,
+			&sa.Reason,
+			&sa.MarkedAt,
+			&sa.Location.Latitude,
+			&sa.Location.Longitude,
+			&sa.Location.AccuracyMeters,
+			&sa.Location.DistanceMeters,
+			&sa.OverriddenBy,
+			&sa.OverriddenAt,
+			&sa.Student.ID,
+			&sa.Student.Email,
+			&sa.Student.Name,
+			&sa.Student.Role,
+			&sa.Student.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan student attendance: %w", err)
+		}
+		result = append(result, sa)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *attendanceRepository) CreateGeofenceViolation(ctx context.Context, violation *models.GeofenceViolation) error {
+	query := `
+		INSERT INTO geofence_violations (
+			id, class_id, session_id, student_id,
+			latitude, longitude, accuracy_m, distance_m, attempted_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		violation.ID,
+		violation.ClassID,
+		violation.SessionID,
+		violation.StudentID,
+		violation.Latitude,
+		violation.Longitude,
+		violation.AccuracyMeters,
+		violation.DistanceMeters,
+		violation.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create geofence violation: %w", err)
+	}
+
+	return nil
+}
+
+// GetGeofenceViolationsByClassID returns out-of-range attempts for a class, newest first.
+func (r *attendanceRepository) GetGeofenceViolationsByClassID(
+	ctx context.Context, classID uuid.UUID,
+) ([]models.GeofenceViolationWithStudent, error) {
+	query := `
+		SELECT v.id, v.session_id, v.latitude, v.longitude, v.accuracy_m, v.distance_m, v.attempted_at,
+			u.id, u.email, u.name, u.role, u.created_at
+		FROM geofence_violations v
+		JOIN users u ON v.student_id = u.id
+		WHERE v.class_id = $1
+		ORDER BY v.attempted_at DESC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query geofence violations: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.GeofenceViolationWithStudent
+	for rows.Next() {
+		var v models.GeofenceViolationWithStudent
+		if err := rows.Scan(
+			&v.ID,
+			&v.SessionID,
+			&v.Latitude,
+			&v.Longitude,
+			&v.AccuracyMeters,
+			&v.DistanceMeters,
+			&v.AttemptedAt,
+			&v.Student.ID,
+			&v.Student.Email,
+			&v.Student.Name,
+			&v.Student.Role,
+			&v.Student.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan geofence violation: %w", err)
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
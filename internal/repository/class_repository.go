@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -15,11 +16,34 @@ import (
 type ClassRepository interface {
 	Create(ctx context.Context, class *models.Class) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Class, error)
+	// GetByIDForUpdate loads a class and locks it until the transaction ends,
+	// serializing enrollments so capacity is never exceeded.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Class, error)
 	GetByCode(ctx context.Context, code string) (*models.Class, error)
-	GetByTeacherID(ctx context.Context, teacherID uuid.UUID) ([]models.Class, error)
+	// GetByStaffUserID returns the classes the user is on the staff of,
+	// either the active or the archived ones.
+	GetByStaffUserID(ctx context.Context, userID uuid.UUID, archived bool) ([]models.Class, error)
+	// ExistsByTeacherID reports whether the user owns any class, archived or not.
+	ExistsByTeacherID(ctx context.Context, teacherID uuid.UUID) (bool, error)
+	UpdateName(ctx context.Context, id uuid.UUID, name string) error
+	UpdateGeofence(ctx context.Context, id uuid.UUID, geofence *models.Geofence) error
+	// UpdateCode replaces the join code and resets its use count.
+	UpdateCode(ctx context.Context, id uuid.UUID, code string) error
+	UpdateCodePolicy(ctx context.Context, id uuid.UUID, policy *models.CodePolicy) error
+	// UseCode counts a join by code. It returns ErrNotFound if the code has
+	// no uses left, so concurrent joins cannot exceed the limit.
+	UseCode(ctx context.Context, id uuid.UUID) error
+	UpdateEnrollmentPolicy(ctx context.Context, id uuid.UUID, policy *models.EnrollmentPolicy) error
+	// SetArchived archives a class at archivedAt, or restores it when nil.
+	SetArchived(ctx context.Context, id uuid.UUID, archivedAt *time.Time) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// classColumns is the column list every class query selects from "classes c", in scanClass order.
+const classColumns = `c.id, c.name, c.code, c.teacher_id, c.geofence_enabled, c.latitude, c.longitude,
+		c.geofence_radius_m, c.code_enabled, c.code_expires_at, c.code_max_uses, c.code_uses,
+		c.enrollment_requires_approval, c.capacity, c.archived_at, c.created_at`
+
 type classRepository struct {
 	pool *pgxpool.Pool
 }
@@ -34,7 +58,7 @@ func (r *classRepository) Create(ctx context.Context, class *models.Class) error
 		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
 		class.ID,
 		class.Name,
 		class.Code,
@@ -54,19 +78,12 @@ func (r *classRepository) Create(ctx context.Context, class *models.Class) error
 
 func (r *classRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Class, error) {
 	query := `
-		SELECT id, name, code, teacher_id, created_at
-		FROM classes
-		WHERE id = $1
+		SELECT ` + classColumns + `
+		FROM classes c
+		WHERE c.id = $1
 	`
 
-	class := &models.Class{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&class.ID,
-		&class.Name,
-		&class.Code,
-		&class.TeacherID,
-		&class.CreatedAt,
-	)
+	class, err := scanClass(conn(ctx, r.pool).QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -77,21 +94,33 @@ func (r *classRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Cl
 	return class, nil
 }
 
+func (r *classRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Class, error) {
+	query := `
+		SELECT ` + classColumns + `
+		FROM classes c
+		WHERE c.id = $1
+		FOR UPDATE
+	`
+
+	class, err := scanClass(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to lock class: %w", err)
+	}
+
+	return class, nil
+}
+
 func (r *classRepository) GetByCode(ctx context.Context, code string) (*models.Class, error) {
 	query := `
-		SELECT id, name, code, teacher_id, created_at
-		FROM classes
-		WHERE code = $1
+		SELECT ` + classColumns + `
+		FROM classes c
+		WHERE c.code = $1
 	`
 
-	class := &models.Class{}
-	err := r.pool.QueryRow(ctx, query, code).Scan(
-		&class.ID,
-		&class.Name,
-		&class.Code,
-		&class.TeacherID,
-		&class.CreatedAt,
-	)
+	class, err := scanClass(conn(ctx, r.pool).QueryRow(ctx, query, code))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -102,15 +131,16 @@ func (r *classRepository) GetByCode(ctx context.Context, code string) (*models.C
 	return class, nil
 }
 
-func (r *classRepository) GetByTeacherID(ctx context.Context, teacherID uuid.UUID) ([]models.Class, error) {
+func (r *classRepository) GetByStaffUserID(ctx context.Context, userID uuid.UUID, archived bool) ([]models.Class, error) {
 	query := `
-		SELECT id, name, code, teacher_id, created_at
-		FROM classes
-		WHERE teacher_id = $1
-		ORDER BY created_at DESC
+		SELECT ` + classColumns + `
+		FROM classes c
+		JOIN class_staff cs ON cs.class_id = c.id
+		WHERE cs.user_id = $1 AND (c.archived_at IS NOT NULL) = $2
+		ORDER BY c.created_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, teacherID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, userID, archived)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query classes: %w", err)
 	}
@@ -118,17 +148,11 @@ func (r *classRepository) GetByTeacherID(ctx context.Context, teacherID uuid.UUI
 
 	var classes []models.Class
 	for rows.Next() {
-		var class models.Class
-		if err := rows.Scan(
-			&class.ID,
-			&class.Name,
-			&class.Code,
-			&class.TeacherID,
-			&class.CreatedAt,
-		); err != nil {
+		class, err := scanClass(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan class: %w", err)
 		}
-		classes = append(classes, class)
+		classes = append(classes, *class)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -138,10 +162,150 @@ func (r *classRepository) GetByTeacherID(ctx context.Context, teacherID uuid.UUI
 	return classes, nil
 }
 
+func (r *classRepository) ExistsByTeacherID(ctx context.Context, teacherID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM classes WHERE teacher_id = $1)`
+
+	var exists bool
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, teacherID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for classes: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *classRepository) UpdateName(ctx context.Context, id uuid.UUID, name string) error {
+	query := `UPDATE classes SET name = $2 WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, name)
+	if err != nil {
+		return fmt.Errorf("failed to update class name: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) UpdateGeofence(ctx context.Context, id uuid.UUID, geofence *models.Geofence) error {
+	query := `
+		UPDATE classes
+		SET geofence_enabled = $2, latitude = $3, longitude = $4, geofence_radius_m = $5
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query,
+		id,
+		geofence.Enabled,
+		geofence.Latitude,
+		geofence.Longitude,
+		geofence.RadiusMeters,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update class geofence: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) UpdateCode(ctx context.Context, id uuid.UUID, code string) error {
+	query := `UPDATE classes SET code = $2, code_uses = 0 WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, code)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateKey
+		}
+		return fmt.Errorf("failed to update class code: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) UpdateCodePolicy(ctx context.Context, id uuid.UUID, policy *models.CodePolicy) error {
+	query := `
+		UPDATE classes
+		SET code_enabled = $2, code_expires_at = $3, code_max_uses = $4
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, policy.Enabled, policy.ExpiresAt, policy.MaxUses)
+	if err != nil {
+		return fmt.Errorf("failed to update class code policy: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) UseCode(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE classes
+		SET code_uses = code_uses + 1
+		WHERE id = $1 AND (code_max_uses IS NULL OR code_uses < code_max_uses)
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to count class code use: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) UpdateEnrollmentPolicy(
+	ctx context.Context, id uuid.UUID, policy *models.EnrollmentPolicy,
+) error {
+	query := `UPDATE classes SET enrollment_requires_approval = $2, capacity = $3 WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, policy.RequiresApproval, policy.Capacity)
+	if err != nil {
+		return fmt.Errorf("failed to update class enrollment policy: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *classRepository) SetArchived(ctx context.Context, id uuid.UUID, archivedAt *time.Time) error {
+	query := `UPDATE classes SET archived_at = $2 WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, archivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update class archive state: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *classRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM classes WHERE id = $1`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete class: %w", err)
 	}
@@ -152,3 +316,30 @@ func (r *classRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func scanClass(row pgx.Row) (*models.Class, error) {
+	class := &models.Class{}
+	err := row.Scan(
+		&class.ID,
+		&class.Name,
+		&class.Code,
+		&class.TeacherID,
+		&class.Geofence.Enabled,
+		&class.Geofence.Latitude,
+		&class.Geofence.Longitude,
+		&class.Geofence.RadiusMeters,
+		&class.CodePolicy.Enabled,
+		&class.CodePolicy.ExpiresAt,
+		&class.CodePolicy.MaxUses,
+		&class.CodePolicy.Uses,
+		&class.Enrollment.RequiresApproval,
+		&class.Enrollment.Capacity,
+		&class.ArchivedAt,
+		&class.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type ClassStaffRepository interface {
+	Create(ctx context.Context, staff *models.ClassStaff) error
+	// GetRole returns the user's staff role in the class, or ErrNotFound.
+	GetRole(ctx context.Context, classID, userID uuid.UUID) (models.ClassRole, error)
+	ListByClassID(ctx context.Context, classID uuid.UUID) ([]models.StaffMember, error)
+	// HasTeachingRole reports whether the user owns or co-teaches any class.
+	HasTeachingRole(ctx context.Context, userID uuid.UUID) (bool, error)
+	// Delete removes a staff assignment and returns it.
+	Delete(ctx context.Context, classID, userID uuid.UUID) (*models.ClassStaff, error)
+}
+
+type classStaffRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewClassStaffRepository(pool *pgxpool.Pool) ClassStaffRepository {
+	return &classStaffRepository{pool: pool}
+}
+
+func (r *classStaffRepository) Create(ctx context.Context, staff *models.ClassStaff) error {
+	query := `
+		INSERT INTO class_staff (class_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query, staff.ClassID, staff.UserID, staff.Role, staff.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateKey
+		}
+		return fmt.Errorf("failed to create class staff: %w", err)
+	}
+
+	return nil
+}
+
+func (r *classStaffRepository) GetRole(ctx context.Context, classID, userID uuid.UUID) (models.ClassRole, error) {
+	query := `SELECT role FROM class_staff WHERE class_id = $1 AND user_id = $2`
+
+	var role models.ClassRole
+	err := conn(ctx, r.pool).QueryRow(ctx, query, classID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get class role: %w", err)
+	}
+
+	return role, nil
+}
+
+// ListByClassID returns a class's staff with user details, owner first.
+func (r *classStaffRepository) ListByClassID(ctx context.Context, classID uuid.UUID) ([]models.StaffMember, error) {
+	query := `
+		SELECT cs.role, cs.created_at, u.id, u.email, u.name, u.role, u.created_at
+		FROM class_staff cs
+		JOIN users u ON cs.user_id = u.id
+		WHERE cs.class_id = $1
+		ORDER BY CASE cs.role WHEN 'owner' THEN 0 WHEN 'co_teacher' THEN 1 ELSE 2 END, u.name ASC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query class staff: %w", err)
+	}
+	defer rows.Close()
+
+	var staff []models.StaffMember
+	for rows.Next() {
+		var m models.StaffMember
+		if err := rows.Scan(
+			&m.Role,
+			&m.AddedAt,
+			&m.User.ID,
+			&m.User.Email,
+			&m.User.Name,
+			&m.User.Role,
+			&m.User.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan class staff: %w", err)
+		}
+		staff = append(staff, m)
+	}
+
+	return staff, rows.Err()
+}
+
+func (r *classStaffRepository) HasTeachingRole(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM class_staff
+			WHERE user_id = $1 AND role IN ('owner', 'co_teacher')
+		)
+	`
+
+	var exists bool
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check class staff roles: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *classStaffRepository) Delete(ctx context.Context, classID, userID uuid.UUID) (*models.ClassStaff, error) {
+	query := `
+		DELETE FROM class_staff
+		WHERE class_id = $1 AND user_id = $2
+		RETURNING class_id, user_id, role, created_at
+	`
+
+	staff := &models.ClassStaff{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, classID, userID).Scan(
+		&staff.ClassID,
+		&staff.UserID,
+		&staff.Role,
+		&staff.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to delete class staff: %w", err)
+	}
+
+	return staff, nil
+}
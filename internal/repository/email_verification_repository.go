@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *models.EmailVerificationToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error)
+	MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error
+	// CountCreatedSince counts tokens issued to a user since a time, for throttling resends.
+	CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+}
+
+type emailVerificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEmailVerificationRepository(pool *pgxpool.Pool) EmailVerificationRepository {
+	return &emailVerificationRepository{pool: pool}
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, token *models.EmailVerificationToken) error {
+	query := `
+		INSERT INTO email_verification_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate loads a token and locks it. Must run inside a transaction.
+func (r *emailVerificationRepository) GetByHashForUpdate(
+	ctx context.Context, tokenHash string,
+) (*models.EmailVerificationToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM email_verification_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	t := &models.EmailVerificationToken{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreatedAt,
+		&t.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *emailVerificationRepository) MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE email_verification_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, userID, usedAt); err != nil {
+		return fmt.Errorf("failed to consume email verification tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *emailVerificationRepository) CountCreatedSince(
+	ctx context.Context, userID uuid.UUID, since time.Time,
+) (int, error) {
+	query := `SELECT COUNT(*) FROM email_verification_tokens WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, userID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count email verification tokens: %w", err)
+	}
+
+	return count, nil
+}
@@ -4,21 +4,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/tahiriqbal095/attendify/internal/models"
 )
 
+// EnrollmentRepository stores enrollments in every status. Unless a method
+// says otherwise it only sees active ones, which make up the roster.
 type EnrollmentRepository interface {
 	Create(ctx context.Context, enrollment *models.Enrollment) error
+	// Get returns a student's enrollment in a class in any status.
+	Get(ctx context.Context, classID, studentID uuid.UUID) (*models.Enrollment, error)
 	GetByClassID(ctx context.Context, classID uuid.UUID) ([]models.Enrollment, error)
 	GetByStudentID(ctx context.Context, studentID uuid.UUID) ([]models.Enrollment, error)
 	IsEnrolled(ctx context.Context, classID, studentID uuid.UUID) (bool, error)
-	Delete(ctx context.Context, classID, studentID uuid.UUID) error
+	CountActive(ctx context.Context, classID uuid.UUID) (int, error)
+	// UpdateStatus moves an enrollment from one status to another, stamping
+	// it with at. It returns ErrNotFound unless the enrollment is in from.
+	UpdateStatus(
+		ctx context.Context, classID, studentID uuid.UUID, from, to models.EnrollmentStatus, at time.Time,
+	) (*models.Enrollment, error)
+	// NextWaitlisted returns the longest-waiting waitlisted enrollment.
+	NextWaitlisted(ctx context.Context, classID uuid.UUID) (*models.Enrollment, error)
+	// Delete removes an enrollment in any status and returns it.
+	Delete(ctx context.Context, classID, studentID uuid.UUID) (*models.Enrollment, error)
 	GetClassesWithDetailsByStudentID(ctx context.Context, studentID uuid.UUID) ([]models.EnrollmentWithClass, error)
 	GetStudentsWithDetailsByClassID(ctx context.Context, classID uuid.UUID) ([]models.StudentInClass, error)
+	// ListRequestsByClassID returns a class's pending and waitlisted
+	// enrollments, or only those in status if it is set, oldest first.
+	ListRequestsByClassID(
+		ctx context.Context, classID uuid.UUID, status models.EnrollmentStatus,
+	) ([]models.EnrollmentRequest, error)
+	// ListRequestsByStudentID returns a student's pending and waitlisted enrollments.
+	ListRequestsByStudentID(ctx context.Context, studentID uuid.UUID) ([]models.MyEnrollmentRequest, error)
 }
 
 type enrollmentRepository struct {
@@ -31,14 +53,15 @@ func NewEnrollmentRepository(pool *pgxpool.Pool) EnrollmentRepository {
 
 func (r *enrollmentRepository) Create(ctx context.Context, enrollment *models.Enrollment) error {
 	query := `
-		INSERT INTO enrollments (id, class_id, student_id, enrolled_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO enrollments (id, class_id, student_id, status, enrolled_at)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
 		enrollment.ID,
 		enrollment.ClassID,
 		enrollment.StudentID,
+		enrollment.Status,
 		enrollment.EnrolledAt,
 	)
 	if err != nil {
@@ -54,13 +77,13 @@ func (r *enrollmentRepository) Create(ctx context.Context, enrollment *models.En
 
 func (r *enrollmentRepository) GetByClassID(ctx context.Context, classID uuid.UUID) ([]models.Enrollment, error) {
 	query := `
-		SELECT id, class_id, student_id, enrolled_at
+		SELECT id, class_id, student_id, status, enrolled_at
 		FROM enrollments
-		WHERE class_id = $1
+		WHERE class_id = $1 AND status = 'active'
 		ORDER BY enrolled_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, classID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query enrollments: %w", err)
 	}
@@ -69,7 +92,7 @@ func (r *enrollmentRepository) GetByClassID(ctx context.Context, classID uuid.UU
 	var enrollments []models.Enrollment
 	for rows.Next() {
 		var e models.Enrollment
-		if err := rows.Scan(&e.ID, &e.ClassID, &e.StudentID, &e.EnrolledAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.ClassID, &e.StudentID, &e.Status, &e.EnrolledAt); err != nil {
 			return nil, fmt.Errorf("failed to scan enrollment: %w", err)
 		}
 		enrollments = append(enrollments, e)
@@ -80,13 +103,13 @@ func (r *enrollmentRepository) GetByClassID(ctx context.Context, classID uuid.UU
 
 func (r *enrollmentRepository) GetByStudentID(ctx context.Context, studentID uuid.UUID) ([]models.Enrollment, error) {
 	query := `
-		SELECT id, class_id, student_id, enrolled_at
+		SELECT id, class_id, student_id, status, enrolled_at
 		FROM enrollments
-		WHERE student_id = $1
+		WHERE student_id = $1 AND status = 'active'
 		ORDER BY enrolled_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, studentID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, studentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query enrollments: %w", err)
 	}
@@ -95,7 +118,7 @@ func (r *enrollmentRepository) GetByStudentID(ctx context.Context, studentID uui
 	var enrollments []models.Enrollment
 	for rows.Next() {
 		var e models.Enrollment
-		if err := rows.Scan(&e.ID, &e.ClassID, &e.StudentID, &e.EnrolledAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.ClassID, &e.StudentID, &e.Status, &e.EnrolledAt); err != nil {
 			return nil, fmt.Errorf("failed to scan enrollment: %w", err)
 		}
 		enrollments = append(enrollments, e)
@@ -105,10 +128,14 @@ func (r *enrollmentRepository) GetByStudentID(ctx context.Context, studentID uui
 }
 
 func (r *enrollmentRepository) IsEnrolled(ctx context.Context, classID, studentID uuid.UUID) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM enrollments WHERE class_id = $1 AND student_id = $2)`
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM enrollments WHERE class_id = $1 AND student_id = $2 AND status = 'active'
+		)
+	`
 
 	var exists bool
-	err := r.pool.QueryRow(ctx, query, classID, studentID).Scan(&exists)
+	err := conn(ctx, r.pool).QueryRow(ctx, query, classID, studentID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check enrollment: %w", err)
 	}
@@ -116,32 +143,89 @@ func (r *enrollmentRepository) IsEnrolled(ctx context.Context, classID, studentI
 	return exists, nil
 }
 
-func (r *enrollmentRepository) Delete(ctx context.Context, classID, studentID uuid.UUID) error {
-	query := `DELETE FROM enrollments WHERE class_id = $1 AND student_id = $2`
+func (r *enrollmentRepository) CountActive(ctx context.Context, classID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM enrollments WHERE class_id = $1 AND status = 'active'`
 
-	result, err := r.pool.Exec(ctx, query, classID, studentID)
-	if err != nil {
-		return fmt.Errorf("failed to delete enrollment: %w", err)
+	var count int
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, classID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count enrollments: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return ErrNotFound
+	return count, nil
+}
+
+func (r *enrollmentRepository) Get(ctx context.Context, classID, studentID uuid.UUID) (*models.Enrollment, error) {
+	query := `
+		SELECT id, class_id, student_id, status, enrolled_at
+		FROM enrollments
+		WHERE class_id = $1 AND student_id = $2
+	`
+
+	return r.scanOne(ctx, query, classID, studentID)
+}
+
+func (r *enrollmentRepository) UpdateStatus(
+	ctx context.Context, classID, studentID uuid.UUID, from, to models.EnrollmentStatus, at time.Time,
+) (*models.Enrollment, error) {
+	query := `
+		UPDATE enrollments
+		SET status = $4, enrolled_at = $5
+		WHERE class_id = $1 AND student_id = $2 AND status = $3
+		RETURNING id, class_id, student_id, status, enrolled_at
+	`
+
+	return r.scanOne(ctx, query, classID, studentID, from, to, at)
+}
+
+func (r *enrollmentRepository) NextWaitlisted(ctx context.Context, classID uuid.UUID) (*models.Enrollment, error) {
+	query := `
+		SELECT id, class_id, student_id, status, enrolled_at
+		FROM enrollments
+		WHERE class_id = $1 AND status = 'waitlisted'
+		ORDER BY enrolled_at, id
+		LIMIT 1
+	`
+
+	return r.scanOne(ctx, query, classID)
+}
+
+func (r *enrollmentRepository) Delete(ctx context.Context, classID, studentID uuid.UUID) (*models.Enrollment, error) {
+	query := `
+		DELETE FROM enrollments
+		WHERE class_id = $1 AND student_id = $2
+		RETURNING id, class_id, student_id, status, enrolled_at
+	`
+
+	return r.scanOne(ctx, query, classID, studentID)
+}
+
+// scanOne runs a query returning at most one enrollment row.
+func (r *enrollmentRepository) scanOne(ctx context.Context, query string, args ...any) (*models.Enrollment, error) {
+	var e models.Enrollment
+	err := conn(ctx, r.pool).QueryRow(ctx, query, args...).Scan(
+		&e.ID, &e.ClassID, &e.StudentID, &e.Status, &e.EnrolledAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query enrollment: %w", err)
 	}
 
-	return nil
+	return &e, nil
 }
 
-// GetClassesWithDetailsByStudentID returns enrolled classes with full class details.
+// GetClassesWithDetailsByStudentID returns enrolled active classes with full class details.
 func (r *enrollmentRepository) GetClassesWithDetailsByStudentID(ctx context.Context, studentID uuid.UUID) ([]models.EnrollmentWithClass, error) {
 	query := `
 		SELECT e.id, e.enrolled_at, c.id, c.name, c.code, c.teacher_id, c.created_at
 		FROM enrollments e
 		JOIN classes c ON e.class_id = c.id
-		WHERE e.student_id = $1
+		WHERE e.student_id = $1 AND e.status = 'active' AND c.archived_at IS NULL
 		ORDER BY e.enrolled_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, studentID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, studentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query enrollments with classes: %w", err)
 	}
@@ -167,7 +251,7 @@ func (r *enrollmentRepository) GetClassesWithDetailsByStudentID(ctx context.Cont
 	return result, rows.Err()
 }
 
-// GetStudentsWithDetailsByClassID returns enrolled students with full user details.
+// GetStudentsWithDetailsByClassID returns actively enrolled students with full user details.
 func (r *enrollmentRepository) GetStudentsWithDetailsByClassID(
 	ctx context.Context, classID uuid.UUID,
 ) ([]models.StudentInClass, error) {
@@ -175,11 +259,11 @@ func (r *enrollmentRepository) GetStudentsWithDetailsByClassID(
 		SELECT e.id, e.enrolled_at, u.id, u.email, u.name, u.role, u.created_at
 		FROM enrollments e
 		JOIN users u ON e.student_id = u.id
-		WHERE e.class_id = $1
+		WHERE e.class_id = $1 AND e.status = 'active'
 		ORDER BY u.name ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, classID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query students in class: %w", err)
 	}
@@ -204,3 +288,94 @@ func (r *enrollmentRepository) GetStudentsWithDetailsByClassID(
 
 	return result, rows.Err()
 }
+
+func (r *enrollmentRepository) ListRequestsByClassID(
+	ctx context.Context, classID uuid.UUID, status models.EnrollmentStatus,
+) ([]models.EnrollmentRequest, error) {
+	query := `
+		SELECT e.id, e.status, e.enrolled_at,
+			CASE WHEN e.status = 'waitlisted'
+				THEN ROW_NUMBER() OVER (PARTITION BY e.status ORDER BY e.enrolled_at, e.id)
+			END,
+			u.id, u.email, u.name, u.role, u.created_at
+		FROM enrollments e
+		JOIN users u ON e.student_id = u.id
+		WHERE e.class_id = $1 AND e.status <> 'active' AND ($2::text = '' OR e.status = $2::text)
+		ORDER BY e.enrolled_at, e.id
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrollment requests: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.EnrollmentRequest
+	for rows.Next() {
+		var er models.EnrollmentRequest
+		if err := rows.Scan(
+			&er.ID,
+			&er.Status,
+			&er.RequestedAt,
+			&er.Position,
+			&er.Student.ID,
+			&er.Student.Email,
+			&er.Student.Name,
+			&er.Student.Role,
+			&er.Student.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment request: %w", err)
+		}
+		result = append(result, er)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *enrollmentRepository) ListRequestsByStudentID(
+	ctx context.Context, studentID uuid.UUID,
+) ([]models.MyEnrollmentRequest, error) {
+	// Positions are numbered across the whole waitlist before picking out the student's rows.
+	query := `
+		SELECT q.id, q.status, q.enrolled_at, q.position, c.id, c.name, c.code, c.teacher_id, c.created_at
+		FROM (
+			SELECT e.id, e.class_id, e.student_id, e.status, e.enrolled_at,
+				CASE WHEN e.status = 'waitlisted'
+					THEN ROW_NUMBER() OVER (PARTITION BY e.class_id, e.status ORDER BY e.enrolled_at, e.id)
+				END AS position
+			FROM enrollments e
+			WHERE e.status <> 'active'
+				AND e.class_id IN (SELECT class_id FROM enrollments WHERE student_id = $1)
+		) q
+		JOIN classes c ON q.class_id = c.id
+		WHERE q.student_id = $1
+		ORDER BY q.enrolled_at DESC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrollment requests: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.MyEnrollmentRequest
+	for rows.Next() {
+		var mr models.MyEnrollmentRequest
+		if err := rows.Scan(
+			&mr.ID,
+			&mr.Status,
+			&mr.RequestedAt,
+			&mr.Position,
+			&mr.Class.ID,
+			&mr.Class.Name,
+			&mr.Class.Code,
+			&mr.Class.TeacherID,
+			&mr.Class.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment request: %w", err)
+		}
+		result = append(result, mr)
+	}
+
+	return result, rows.Err()
+}
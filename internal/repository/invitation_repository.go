@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) error
+	// GetByHashForUpdate loads an invitation and locks it so it can only be
+	// accepted once. Must run inside a transaction.
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	// ListOutstandingByClassID returns the class's invitations that can still
+	// be accepted at now, newest first.
+	ListOutstandingByClassID(ctx context.Context, classID uuid.UUID, now time.Time) ([]models.Invitation, error)
+	// Revoke revokes one outstanding invitation, returning ErrNotFound if
+	// there is none with that id in the class.
+	Revoke(ctx context.Context, classID, id uuid.UUID, at time.Time) (*models.Invitation, error)
+	// RevokeByEmail revokes every outstanding invitation of an address to a
+	// class, ignoring case.
+	RevokeByEmail(ctx context.Context, classID uuid.UUID, email string, at time.Time) error
+	MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) error
+}
+
+// invitationColumns is the column list every invitation query selects, in scanInvitation order.
+const invitationColumns = `id, class_id, email, token_hash, invited_by, expires_at, created_at,
+		accepted_at, accepted_by, revoked_at`
+
+type invitationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewInvitationRepository(pool *pgxpool.Pool) InvitationRepository {
+	return &invitationRepository{pool: pool}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	query := `
+		INSERT INTO invitations (id, class_id, email, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		invitation.ID,
+		invitation.ClassID,
+		invitation.Email,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *invitationRepository) GetByHashForUpdate(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitations
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	invitation, err := scanInvitation(conn(ctx, r.pool).QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *invitationRepository) ListOutstandingByClassID(
+	ctx context.Context, classID uuid.UUID, now time.Time,
+) ([]models.Invitation, error) {
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitations
+		WHERE class_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (r *invitationRepository) Revoke(
+	ctx context.Context, classID, id uuid.UUID, at time.Time,
+) (*models.Invitation, error) {
+	query := `
+		UPDATE invitations SET revoked_at = $3
+		WHERE class_id = $1 AND id = $2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $3
+		RETURNING ` + invitationColumns
+
+	invitation, err := scanInvitation(conn(ctx, r.pool).QueryRow(ctx, query, classID, id, at))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *invitationRepository) RevokeByEmail(
+	ctx context.Context, classID uuid.UUID, email string, at time.Time,
+) error {
+	query := `
+		UPDATE invitations SET revoked_at = $3
+		WHERE class_id = $1 AND lower(email) = lower($2) AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, classID, email, at); err != nil {
+		return fmt.Errorf("failed to revoke invitations: %w", err)
+	}
+
+	return nil
+}
+
+func (r *invitationRepository) MarkAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE invitations SET accepted_at = $3, accepted_by = $2 WHERE id = $1 AND accepted_at IS NULL`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanInvitation(row pgx.Row) (*models.Invitation, error) {
+	invitation := &models.Invitation{}
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.ClassID,
+		&invitation.Email,
+		&invitation.TokenHash,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.CreatedAt,
+		&invitation.AcceptedAt,
+		&invitation.AcceptedBy,
+		&invitation.RevokedAt,
+	)
+	return invitation, err
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type LoginThrottleRepository interface {
+	GetMany(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+	// RecordFailure counts a failed attempt and returns the updated row.
+	// Failures older than window are forgotten before counting.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error)
+	Block(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, key string) error
+	DeleteInactiveSince(ctx context.Context, before time.Time) error
+}
+
+type loginThrottleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginThrottleRepository(pool *pgxpool.Pool) LoginThrottleRepository {
+	return &loginThrottleRepository{pool: pool}
+}
+
+func (r *loginThrottleRepository) GetMany(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	query := `
+		SELECT key, failures, last_failure_at, blocked_until
+		FROM login_throttles
+		WHERE key = ANY($1)
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttles: %w", err)
+	}
+	defer rows.Close()
+
+	var throttles []models.LoginThrottle
+	for rows.Next() {
+		var t models.LoginThrottle
+		if err := rows.Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.BlockedUntil); err != nil {
+			return nil, fmt.Errorf("failed to scan login throttle: %w", err)
+		}
+		throttles = append(throttles, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating login throttles: %w", err)
+	}
+
+	return throttles, nil
+}
+
+func (r *loginThrottleRepository) RecordFailure(
+	ctx context.Context, key string, now time.Time, window time.Duration,
+) (*models.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttles.last_failure_at < $3 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, blocked_until
+	`
+
+	t := &models.LoginThrottle{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, key, now, now.Add(-window)).Scan(
+		&t.Key,
+		&t.Failures,
+		&t.LastFailureAt,
+		&t.BlockedUntil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *loginThrottleRepository) Block(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_throttles SET blocked_until = $2 WHERE key = $1`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, key, until); err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, key string) error {
+	query := `DELETE FROM login_throttles WHERE key = $1`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteInactiveSince forgets counters with no failures since before,
+// unless they are still blocking attempts.
+func (r *loginThrottleRepository) DeleteInactiveSince(ctx context.Context, before time.Time) error {
+	query := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (blocked_until IS NULL OR blocked_until < $1)
+	`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to delete login throttles: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type MFARepository interface {
+	// SavePending stores a new unconfirmed secret, replacing any earlier unconfirmed one.
+	// Returns ErrDuplicateKey if the user already has confirmed MFA.
+	SavePending(ctx context.Context, mfa *models.UserMFA) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error)
+	Confirm(ctx context.Context, userID uuid.UUID, confirmedAt time.Time) error
+	// UseStep records an accepted TOTP step. Returns ErrNotFound if the step
+	// is not newer than the last one used, i.e. the code was replayed.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// UseRecoveryCode consumes a code. Returns ErrNotFound if it is unknown or spent.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error
+
+	CreateChallenge(ctx context.Context, challenge *models.MFAChallenge) error
+	GetChallengeByHashForUpdate(ctx context.Context, tokenHash string) (*models.MFAChallenge, error)
+	IncrementChallengeAttempts(ctx context.Context, id uuid.UUID) error
+	MarkChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+type mfaRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) MFARepository {
+	return &mfaRepository{pool: pool}
+}
+
+func (r *mfaRepository) SavePending(ctx context.Context, mfa *models.UserMFA) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+		WHERE user_mfa.confirmed_at IS NULL
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, mfa.UserID, mfa.Secret, mfa.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrDuplicateKey
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+
+	m := &models.UserMFA{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, userID).Scan(
+		&m.UserID,
+		&m.Secret,
+		&m.ConfirmedAt,
+		&m.LastUsedStep,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+
+	return m, nil
+}
+
+func (r *mfaRepository) Confirm(ctx context.Context, userID uuid.UUID, confirmedAt time.Time) error {
+	query := `UPDATE user_mfa SET confirmed_at = $2 WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, userID, confirmedAt)
+	if err != nil {
+		return fmt.Errorf("failed to confirm mfa: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record mfa step: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM user_mfa WHERE user_id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete mfa: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	db := conn(ctx, r.pool)
+
+	if _, err := db.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `
+		INSERT INTO mfa_recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`
+	if _, err := db.Exec(ctx, query, userID, codeHashes); err != nil {
+		return fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(
+	ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time,
+) error {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, userID, codeHash, usedAt)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) CreateChallenge(ctx context.Context, challenge *models.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		challenge.ID,
+		challenge.UserID,
+		challenge.TokenHash,
+		challenge.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetChallengeByHashForUpdate loads a challenge and locks it. Must run inside a transaction.
+func (r *mfaRepository) GetChallengeByHashForUpdate(
+	ctx context.Context, tokenHash string,
+) (*models.MFAChallenge, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, attempts, used_at
+		FROM mfa_challenges
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	c := &models.MFAChallenge{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, tokenHash).Scan(
+		&c.ID,
+		&c.UserID,
+		&c.TokenHash,
+		&c.ExpiresAt,
+		&c.Attempts,
+		&c.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *mfaRepository) IncrementChallengeAttempts(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) MarkChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE mfa_challenges SET used_at = $2 WHERE id = $1`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, id, usedAt); err != nil {
+		return fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	return nil
+}
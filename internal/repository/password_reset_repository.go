@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// MarkUsedByUserID consumes every outstanding token of a user.
+	MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error
+}
+
+type passwordResetRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool) PasswordResetRepository {
+	return &passwordResetRepository{pool: pool}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate loads a token and locks it so it can only be redeemed once.
+// Must run inside a transaction.
+func (r *passwordResetRepository) GetByHashForUpdate(
+	ctx context.Context, tokenHash string,
+) (*models.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	t := &models.PasswordResetToken{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreatedAt,
+		&t.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *passwordResetRepository) MarkUsedByUserID(ctx context.Context, userID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, userID, usedAt); err != nil {
+		return fmt.Errorf("failed to consume password reset tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, jti, userID uuid.UUID, expiresAt, revokedAt time.Time) error
+	Exists(ctx context.Context, jti uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	// RevokeUserBefore rejects every token of a user issued before the cutoff.
+	RevokeUserBefore(ctx context.Context, userID uuid.UUID, cutoff time.Time) error
+	// GetUserCutoff returns the user's cutoff, or nil if none is set.
+	GetUserCutoff(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	// DeleteUserCutoffsBefore drops cutoffs that no live token can predate.
+	DeleteUserCutoffsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type revokedTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRevokedTokenRepository(pool *pgxpool.Pool) RevokedTokenRepository {
+	return &revokedTokenRepository{pool: pool}
+}
+
+// Create records a revoked token. Revoking the same token twice is a no-op.
+func (r *revokedTokenRepository) Create(
+	ctx context.Context, jti, userID uuid.UUID, expiresAt, revokedAt time.Time,
+) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, jti, userID, expiresAt, revokedAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *revokedTokenRepository) Exists(ctx context.Context, jti uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var exists bool
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, jti).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check revoked token: %w", err)
+	}
+
+	return exists, nil
+}
+
+// DeleteExpired drops entries for tokens that would be rejected anyway.
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *revokedTokenRepository) RevokeUserBefore(ctx context.Context, userID uuid.UUID, cutoff time.Time) error {
+	query := `
+		INSERT INTO user_token_revocations (user_id, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET revoked_before = GREATEST(user_token_revocations.revoked_before, EXCLUDED.revoked_before)
+	`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, userID, cutoff); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *revokedTokenRepository) GetUserCutoff(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	query := `SELECT revoked_before FROM user_token_revocations WHERE user_id = $1`
+
+	var cutoff time.Time
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, userID).Scan(&cutoff); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user token cutoff: %w", err)
+	}
+
+	return &cutoff, nil
+}
+
+func (r *revokedTokenRepository) DeleteUserCutoffsBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM user_token_revocations WHERE revoked_before <= $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user token cutoffs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.ClassSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ClassSession, error)
+	GetOpenByClassID(ctx context.Context, classID uuid.UUID, now time.Time) (*models.ClassSession, error)
+	GetByClassID(ctx context.Context, classID uuid.UUID) ([]models.ClassSession, error)
+	GetOpenByClassIDs(ctx context.Context, classIDs []uuid.UUID, now time.Time) ([]models.ClassSession, error)
+	Close(ctx context.Context, id uuid.UUID, closedAt time.Time) error
+	CloseExpired(ctx context.Context, now time.Time) ([]models.ClassSession, error)
+	CloseExpiredByClassID(ctx context.Context, classID uuid.UUID, now time.Time) ([]models.ClassSession, error)
+}
+
+type sessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pool *pgxpool.Pool) SessionRepository {
+	return &sessionRepository{pool: pool}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.ClassSession) error {
+	query := `
+		INSERT INTO class_sessions (id, class_id, opened_by, opened_at, expires_at, late_at, secret)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		session.ID,
+		session.ClassID,
+		session.OpenedBy,
+		session.OpenedAt,
+		session.ExpiresAt,
+		session.LateAt,
+		session.Secret,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateKey
+		}
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ClassSession, error) {
+	query := `
+		SELECT id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+		FROM class_sessions
+		WHERE id = $1
+	`
+
+	session := &models.ClassSession{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, id).Scan(
+		&session.ID,
+		&session.ClassID,
+		&session.OpenedBy,
+		&session.OpenedAt,
+		&session.ExpiresAt,
+		&session.ClosedAt,
+		&session.LateAt,
+		&session.Secret,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session by id: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetOpenByClassID returns the session currently accepting attendance for a class.
+func (r *sessionRepository) GetOpenByClassID(
+	ctx context.Context, classID uuid.UUID, now time.Time,
+) (*models.ClassSession, error) {
+	query := `
+		SELECT id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+		FROM class_sessions
+		WHERE class_id = $1 AND closed_at IS NULL AND expires_at > $2
+	`
+
+	session := &models.ClassSession{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, classID, now).Scan(
+		&session.ID,
+		&session.ClassID,
+		&session.OpenedBy,
+		&session.OpenedAt,
+		&session.ExpiresAt,
+		&session.ClosedAt,
+		&session.LateAt,
+		&session.Secret,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get open session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) GetByClassID(ctx context.Context, classID uuid.UUID) ([]models.ClassSession, error) {
+	query := `
+		SELECT id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+		FROM class_sessions
+		WHERE class_id = $1
+		ORDER BY opened_at DESC
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// GetOpenByClassIDs returns the sessions of the given classes that are currently accepting attendance.
+func (r *sessionRepository) GetOpenByClassIDs(
+	ctx context.Context, classIDs []uuid.UUID, now time.Time,
+) ([]models.ClassSession, error) {
+	query := `
+		SELECT id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+		FROM class_sessions
+		WHERE class_id = ANY($1) AND closed_at IS NULL AND expires_at > $2
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classIDs, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// Close marks an open session as closed. Returns ErrNotFound if it was already closed.
+func (r *sessionRepository) Close(ctx context.Context, id uuid.UUID, closedAt time.Time) error {
+	query := `UPDATE class_sessions SET closed_at = $2 WHERE id = $1 AND closed_at IS NULL`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, closedAt)
+	if err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CloseExpired closes every session whose expiry has passed and returns them.
+func (r *sessionRepository) CloseExpired(ctx context.Context, now time.Time) ([]models.ClassSession, error) {
+	query := `
+		UPDATE class_sessions
+		SET closed_at = expires_at
+		WHERE closed_at IS NULL AND expires_at <= $1
+		RETURNING id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close expired sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// CloseExpiredByClassID is CloseExpired limited to one class.
+func (r *sessionRepository) CloseExpiredByClassID(
+	ctx context.Context, classID uuid.UUID, now time.Time,
+) ([]models.ClassSession, error) {
+	query := `
+		UPDATE class_sessions
+		SET closed_at = expires_at
+		WHERE class_id = $1 AND closed_at IS NULL AND expires_at <= $2
+		RETURNING id, class_id, opened_by, opened_at, expires_at, closed_at, late_at, secret
+	`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, classID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close expired sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+func scanSessions(rows pgx.Rows) ([]models.ClassSession, error) {
+	var sessions []models.ClassSession
+	for rows.Next() {
+		var s models.ClassSession
+		if err := rows.Scan(
+			&s.ID,
+			&s.ClassID,
+			&s.OpenedBy,
+			&s.OpenedAt,
+			&s.ExpiresAt,
+			&s.ClosedAt,
+			&s.LateAt,
+			&s.Secret,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
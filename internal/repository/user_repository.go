@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -16,8 +18,24 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error
+	// List returns matching users, newest first.
+	List(ctx context.Context, filter *models.UserFilter) ([]models.User, error)
+	ExistsWithRole(ctx context.Context, role models.Role) (bool, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error
+	// SetDisabled disables the account at disabledAt, or re-enables it when nil.
+	SetDisabled(ctx context.Context, id uuid.UUID, disabledAt *time.Time) error
+	SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error
+	// FillStudentNumber sets the student number unless the user already has one.
+	FillStudentNumber(ctx context.Context, id uuid.UUID, studentNumber string) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// userColumns is the column list every user query selects, in scanUser order.
+const userColumns = `id, email, password_hash, name, role, email_verified_at, disabled_at,
+		password_reset_required, student_number, created_at`
+
 type userRepository struct {
 	pool *pgxpool.Pool
 }
@@ -28,16 +46,18 @@ func NewUserRepository(pool *pgxpool.Pool) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, password_hash, name, role, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, email, password_hash, name, role, email_verified_at, student_number, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
 		user.Name,
 		user.Role,
+		user.EmailVerifiedAt,
+		user.StudentNumber,
 		user.CreatedAt,
 	)
 	if err != nil {
@@ -52,21 +72,14 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	// Emails are unique regardless of case, so this matches at most one user.
 	query := `
-		SELECT id, email, password_hash, name, role, created_at
+		SELECT ` + userColumns + `
 		FROM users
-		WHERE email = $1
+		WHERE lower(email) = lower($1)
 	`
 
-	user := &models.User{}
-	err := r.pool.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Name,
-		&user.Role,
-		&user.CreatedAt,
-	)
+	user, err := scanUser(conn(ctx, r.pool).QueryRow(ctx, query, email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -79,26 +92,172 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, role, created_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
 
+	user, err := scanUser(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdatePassword also satisfies an admin's demand for a new password.
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, password_reset_required = FALSE WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified stamps the first verification; later calls keep the original time.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, $2) WHERE id = $1`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, verifiedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) List(ctx context.Context, filter *models.UserFilter) ([]models.User, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(clause string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Query != "" {
+		where("(email ILIKE $? OR name ILIKE $?)", "%"+escapeLike(filter.Query)+"%")
+	}
+	if filter.Role != "" {
+		where("role = $?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		where("(disabled_at IS NOT NULL) = $?", *filter.Disabled)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *userRepository) ExistsWithRole(ctx context.Context, role models.Role) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE role = $1 AND disabled_at IS NULL)`
+
+	var exists bool
+	if err := conn(ctx, r.pool).QueryRow(ctx, query, role).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for role: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	query := `UPDATE users SET role = $2 WHERE id = $1`
+
+	return r.execUpdate(ctx, "failed to update role", query, id, role)
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, id uuid.UUID, disabledAt *time.Time) error {
+	query := `UPDATE users SET disabled_at = $2 WHERE id = $1`
+
+	return r.execUpdate(ctx, "failed to update disabled state", query, id, disabledAt)
+}
+
+func (r *userRepository) SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error {
+	query := `UPDATE users SET password_reset_required = $2 WHERE id = $1`
+
+	return r.execUpdate(ctx, "failed to require password reset", query, id, required)
+}
+
+func (r *userRepository) FillStudentNumber(ctx context.Context, id uuid.UUID, studentNumber string) error {
+	query := `UPDATE users SET student_number = COALESCE(student_number, $2) WHERE id = $1`
+
+	return r.execUpdate(ctx, "failed to set student number", query, id, studentNumber)
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM users WHERE id = $1`
+
+	return r.execUpdate(ctx, "failed to delete user", query, id)
+}
+
+// execUpdate runs a statement against one user, returning ErrNotFound if it matched none.
+func (r *userRepository) execUpdate(ctx context.Context, failure, query string, args ...any) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", failure, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row) (*models.User, error) {
 	user := &models.User{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Name,
 		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.PasswordResetRequired,
+		&user.StudentNumber,
 		&user.CreatedAt,
 	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to get user by id: %w", err)
-	}
+	return user, err
+}
 
-	return user, nil
+// escapeLike makes user input match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
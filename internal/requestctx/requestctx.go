@@ -0,0 +1,40 @@
+// Package requestctx carries per-request metadata (request ID, acting user)
+// through context.Context so services can attribute changes without
+// threading extra parameters through every call.
+package requestctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+type requestIDKey struct{}
+type actorKey struct{}
+
+// Actor is the authenticated user performing the request.
+type Actor struct {
+	UserID uuid.UUID
+	Role   models.Role
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID, or "" outside an HTTP request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the acting user, if the request is authenticated.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/config"
+	"github.com/tahiriqbal095/attendify/internal/db"
+	"github.com/tahiriqbal095/attendify/internal/handler"
+	"github.com/tahiriqbal095/attendify/internal/jwtkeys"
+	"github.com/tahiriqbal095/attendify/internal/mail"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/service"
+	"github.com/tahiriqbal095/attendify/internal/ws"
+)
+
+// dependencies holds the services and handlers shared by every API version.
+type dependencies struct {
+	hub            *ws.Hub
+	outbox         *mail.Outbox
+	authService    service.AuthService
+	revocations    service.RevocationStore
+	loginLimiter   service.LoginLimiter
+	sessionService service.SessionService
+	userService    service.UserService
+
+	authHandler       *handler.AuthHandler
+	classHandler      *handler.ClassHandler
+	enrollmentHandler *handler.EnrollmentHandler
+	rosterHandler     *handler.RosterHandler
+	invitationHandler *handler.InvitationHandler
+	attendanceHandler *handler.AttendanceHandler
+	sessionHandler    *handler.SessionHandler
+	adminHandler      *handler.AdminHandler
+	jwksHandler       *handler.JWKSHandler
+	wsHandler         *handler.WSHandler
+}
+
+// newDependencies wires repositories, services and handlers from the pool and config.
+func newDependencies(cfg *config.Config, logger zerolog.Logger, pool *db.Pool, keys *jwtkeys.KeySet) *dependencies {
+	userRepo := repository.NewUserRepository(pool)
+	classRepo := repository.NewClassRepository(pool)
+	classStaffRepo := repository.NewClassStaffRepository(pool)
+	enrollmentRepo := repository.NewEnrollmentRepository(pool)
+	invitationRepo := repository.NewInvitationRepository(pool)
+	attendanceRepo := repository.NewAttendanceRepository(pool)
+	sessionRepo := repository.NewSessionRepository(pool)
+	auditRepo := repository.NewAuditRepository(pool)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(pool)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(pool)
+	passwordResetRepo := repository.NewPasswordResetRepository(pool)
+	verificationRepo := repository.NewEmailVerificationRepository(pool)
+	mfaRepo := repository.NewMFARepository(pool)
+	unlockRepo := repository.NewAccountUnlockRepository(pool)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(pool)
+	transactor := repository.NewTransactor(pool)
+
+	hub := ws.NewHub(logger)
+	outbox := mail.NewOutbox(newMailer(cfg, logger), logger)
+
+	auditService := service.NewAuditService(auditRepo)
+	revocations := service.NewRevocationStore(revokedTokenRepo, cfg.AccessTokenTTL)
+	loginLimiter := service.NewLoginLimiter(loginThrottleRepo, service.LoginLimitConfig{
+		LockoutThreshold: cfg.LoginLockoutThreshold,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		IPThreshold:      cfg.LoginIPThreshold,
+	})
+	verificationPolicy := service.VerificationPolicy(cfg.EmailVerificationPolicy)
+	authService := service.NewAuthService(
+		userRepo, refreshTokenRepo, passwordResetRepo, verificationRepo, mfaRepo, unlockRepo, loginLimiter,
+		revocations, transactor, auditService, outbox, keys,
+		service.AuthConfig{
+			AccessTTL:            cfg.AccessTokenTTL,
+			RefreshTTL:           cfg.RefreshTokenTTL,
+			PasswordResetTTL:     cfg.PasswordResetTTL,
+			PasswordResetURL:     cfg.FrontendURL + "/reset-password",
+			EmailVerificationTTL: cfg.EmailVerificationTTL,
+			EmailVerificationURL: cfg.FrontendURL + "/verify-email",
+			VerificationPolicy:   verificationPolicy,
+			UnlockURL:            cfg.FrontendURL + "/unlock-account",
+			MFAIssuer:            cfg.MFAIssuer,
+			MFAChallengeTTL:      cfg.MFAChallengeTTL,
+			MFARequiredRoles:     mfaRequiredRoles(cfg.MFARequiredRoles),
+		},
+	)
+	userService := service.NewUserService(userRepo, classRepo, classStaffRepo, authService, transactor, auditService)
+	authorizer := service.NewAuthorizer(classRepo, classStaffRepo, enrollmentRepo)
+	classService := service.NewClassService(
+		classRepo, classStaffRepo, userRepo, sessionRepo, enrollmentRepo, authorizer, transactor, auditService, outbox,
+	)
+	enrollmentService := service.NewEnrollmentService(
+		enrollmentRepo, classRepo, userRepo, authorizer, transactor, auditService, verificationPolicy,
+	)
+	rosterService := service.NewRosterService(
+		userRepo, enrollmentRepo, classRepo, passwordResetRepo, authorizer, transactor, auditService, outbox,
+		service.RosterConfig{
+			InviteTTL:      cfg.InviteTTL,
+			SetPasswordURL: cfg.FrontendURL + "/reset-password",
+		},
+	)
+	invitationService := service.NewInvitationService(
+		invitationRepo, userRepo, classRepo, enrollmentRepo, authorizer, transactor, auditService, outbox,
+		service.InvitationConfig{
+			TTL:       cfg.InviteTTL,
+			AcceptURL: cfg.FrontendURL + "/invitations/accept",
+		},
+	)
+	checkinCodes := service.CheckinCodes{Period: cfg.CheckinCodePeriod, Skew: cfg.CheckinCodeSkew}
+
+	sessionService := service.NewSessionService(
+		sessionRepo, classRepo, attendanceRepo, authorizer, transactor, auditService, hub, checkinCodes,
+		service.SessionConfig{
+			DefaultDuration: cfg.SessionDuration,
+			LateThreshold:   cfg.LateThreshold,
+			CheckinURL:      cfg.CheckinURL,
+		},
+	)
+	attendanceService := service.NewAttendanceService(
+		attendanceRepo, sessionRepo, enrollmentRepo, classRepo, loginThrottleRepo,
+		authorizer, transactor, auditService, hub, checkinCodes,
+	)
+
+	return &dependencies{
+		hub:            hub,
+		outbox:         outbox,
+		authService:    authService,
+		revocations:    revocations,
+		loginLimiter:   loginLimiter,
+		sessionService: sessionService,
+		userService:    userService,
+
+		authHandler:       handler.NewAuthHandler(authService, logger),
+		classHandler:      handler.NewClassHandler(classService, logger),
+		enrollmentHandler: handler.NewEnrollmentHandler(enrollmentService, logger),
+		rosterHandler:     handler.NewRosterHandler(rosterService, logger),
+		invitationHandler: handler.NewInvitationHandler(invitationService, logger),
+		attendanceHandler: handler.NewAttendanceHandler(attendanceService, logger),
+		sessionHandler:    handler.NewSessionHandler(sessionService, logger),
+		adminHandler:      handler.NewAdminHandler(auditService, userService, logger),
+		jwksHandler:       handler.NewJWKSHandler(keys),
+		wsHandler:         handler.NewWSHandler(hub, authService, revocations, authorizer, logger),
+	}
+}
+
+func mfaRequiredRoles(names []string) []models.Role {
+	roles := make([]models.Role, len(names))
+	for i, name := range names {
+		roles[i] = models.Role(name)
+	}
+	return roles
+}
+
+// newMailer picks the mail transport named by MAIL_DRIVER.
+func newMailer(cfg *config.Config, logger zerolog.Logger) mail.Mailer {
+	if cfg.MailDriver == "smtp" {
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	}
+	return mail.NewLogMailer(cfg.MailDir, logger)
+}
+
+// registerRoutes mounts every API version under /api.
+// A future /api/v2 gets its own register function next to registerV1Routes.
+func registerRoutes(engine *gin.Engine, deps *dependencies) {
+	// Well-known URIs are unversioned so other services can find our keys.
+	engine.GET("/.well-known/jwks.json", deps.jwksHandler.Get)
+
+	api := engine.Group("/api")
+	registerV1Routes(api.Group("/v1"), deps)
+}
+
+// registerV1Routes mounts the v1 REST API.
+func registerV1Routes(v1 *gin.RouterGroup, deps *dependencies) {
+	auth := v1.Group("/auth")
+	{
+		auth.POST("/register", deps.authHandler.Register)
+		auth.POST("/login", deps.authHandler.Login)
+		auth.POST("/refresh", deps.authHandler.Refresh)
+		auth.POST("/password-reset", deps.authHandler.RequestPasswordReset)
+		auth.POST("/password-reset/confirm", deps.authHandler.ConfirmPasswordReset)
+		auth.POST("/verify-email", deps.authHandler.VerifyEmail)
+		auth.POST("/verify-email/resend", deps.authHandler.ResendVerification)
+		auth.POST("/unlock", deps.authHandler.UnlockAccount)
+		auth.POST("/mfa/verify", deps.authHandler.VerifyMFA)
+	}
+
+	// Accepting an invitation can create the account, so it needs no sign-in.
+	v1.POST("/invitations/accept/signup", deps.invitationHandler.AcceptWithSignup)
+
+	// The WebSocket upgrade authenticates itself because browsers
+	// cannot send an Authorization header on the handshake.
+	v1.GET("/ws/classes/:id", deps.wsHandler.Connect)
+
+	authenticated := v1.Group("")
+	authenticated.Use(middleware.Auth(deps.authService, deps.revocations))
+
+	// Reachable without a second factor so users the policy covers can enroll.
+	authenticated.POST("/auth/logout", deps.authHandler.Logout)
+	authenticated.POST("/auth/mfa/enroll", deps.authHandler.EnrollMFA)
+	authenticated.POST("/auth/mfa/confirm", deps.authHandler.ConfirmMFA)
+	authenticated.DELETE("/auth/mfa", deps.authHandler.DisableMFA)
+
+	protected := authenticated.Group("")
+	protected.Use(middleware.RequireMFA(deps.authService))
+
+	// Class-scoped permissions are checked by the services against the
+	// caller's role in the class; the middleware only checks global ones.
+	classes := protected.Group("/classes")
+	{
+		classes.POST("", middleware.RequirePermission(models.PermClassCreate), deps.classHandler.Create)
+		classes.GET("", deps.classHandler.List)
+		classes.GET("/:id", deps.classHandler.Get)
+		classes.PATCH("/:id", deps.classHandler.Update)
+		classes.POST("/:id/archive", deps.classHandler.Archive)
+		classes.POST("/:id/restore", deps.classHandler.Restore)
+		classes.PUT("/:id/code", deps.classHandler.UpdateCodePolicy)
+		classes.POST("/:id/code/regenerate", deps.classHandler.RegenerateCode)
+		classes.DELETE("/:id", middleware.RequirePermission(models.PermClassDelete), deps.classHandler.Delete)
+		classes.GET("/:id/students", deps.enrollmentHandler.GetClassStudents)
+		classes.POST("/:id/roster/import", deps.rosterHandler.Import)
+		classes.GET("/:id/invitations", deps.invitationHandler.List)
+		classes.POST("/:id/invitations", deps.invitationHandler.Create)
+		classes.DELETE("/:id/invitations/:invitationId", deps.invitationHandler.Revoke)
+		classes.PUT("/:id/enrollment-policy", deps.enrollmentHandler.UpdatePolicy)
+		classes.GET("/:id/enrollment-requests", deps.enrollmentHandler.ListRequests)
+		classes.POST("/:id/enrollment-requests/:studentId/approve", deps.enrollmentHandler.Approve)
+		classes.POST("/:id/enrollment-requests/:studentId/reject", deps.enrollmentHandler.Reject)
+		classes.GET("/:id/staff", deps.classHandler.ListStaff)
+		classes.POST("/:id/staff", deps.classHandler.AddStaff)
+		classes.DELETE("/:id/staff/:userId", deps.classHandler.RemoveStaff)
+		classes.PUT("/:id/geofence", deps.classHandler.UpdateGeofence)
+		classes.GET("/:id/geofence/violations", deps.attendanceHandler.ListGeofenceViolations)
+
+		classes.POST("/:id/sessions", deps.sessionHandler.Open)
+		classes.GET("/:id/sessions", deps.sessionHandler.List)
+		classes.GET("/:id/sessions/current", deps.sessionHandler.Current)
+		classes.GET("/:id/sessions/current/code", deps.sessionHandler.CheckinCode)
+		classes.GET("/:id/sessions/current/qr", deps.sessionHandler.CheckinQR)
+		classes.POST("/:id/sessions/:sessionId/close", deps.sessionHandler.Close)
+		classes.GET("/:id/sessions/:sessionId/attendance", deps.attendanceHandler.ListForSession)
+		classes.PUT("/:id/sessions/:sessionId/attendance", deps.attendanceHandler.BulkSet)
+		classes.PUT("/:id/sessions/:sessionId/attendance/:studentId", deps.attendanceHandler.Set)
+
+		canMark := middleware.RequirePermission(models.PermAttendanceMark)
+		classes.POST("/:id/attendance", canMark, deps.attendanceHandler.Mark)
+		classes.GET("/:id/attendance/me", canMark, deps.attendanceHandler.ListMine)
+	}
+
+	enrollments := protected.Group("/enrollments")
+	enrollments.Use(middleware.RequirePermission(models.PermClassJoin))
+	{
+		enrollments.POST("", deps.enrollmentHandler.EnrollByCode)
+		enrollments.GET("", deps.enrollmentHandler.GetMyClasses)
+		enrollments.GET("/requests", deps.enrollmentHandler.GetMyRequests)
+		enrollments.DELETE("/:classId", deps.enrollmentHandler.Unenroll)
+	}
+
+	invitations := protected.Group("/invitations")
+	invitations.Use(middleware.RequirePermission(models.PermClassJoin))
+	{
+		invitations.POST("/accept", deps.invitationHandler.Accept)
+	}
+
+	admin := protected.Group("/admin")
+	{
+		admin.GET("/audit-events", middleware.RequirePermission(models.PermAuditView), deps.adminHandler.ListAuditEvents)
+
+		users := admin.Group("/users")
+		users.Use(middleware.RequirePermission(models.PermUserManage))
+		users.GET("", deps.adminHandler.ListUsers)
+		users.GET("/:id", deps.adminHandler.GetUser)
+		users.PUT("/:id/role", deps.adminHandler.ChangeRole)
+		users.POST("/:id/disable", deps.adminHandler.Disable)
+		users.POST("/:id/enable", deps.adminHandler.Enable)
+		users.POST("/:id/password-reset", deps.adminHandler.ForcePasswordReset)
+		users.DELETE("/:id", deps.adminHandler.DeleteUser)
+	}
+}
@@ -2,11 +2,28 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/config"
 	"github.com/tahiriqbal095/attendify/internal/db"
+	"github.com/tahiriqbal095/attendify/internal/jwtkeys"
+	"github.com/tahiriqbal095/attendify/internal/mail"
+	"github.com/tahiriqbal095/attendify/internal/middleware"
+	"github.com/tahiriqbal095/attendify/internal/service"
+	"github.com/tahiriqbal095/attendify/internal/ws"
+)
+
+const (
+	// sessionSweepInterval is how often expired attendance sessions are closed.
+	sessionSweepInterval = 30 * time.Second
+	// revocationCleanupInterval is how often expired token revocations are purged.
+	revocationCleanupInterval = 10 * time.Minute
+	// loginThrottleCleanupInterval is how often quiet failed sign-in counters are purged.
+	loginThrottleCleanupInterval = 10 * time.Minute
 )
 
 type Server struct {
@@ -14,13 +31,37 @@ type Server struct {
 	http   *http.Server
 	logger zerolog.Logger
 	pool   *db.Pool
+
+	hub            *ws.Hub
+	outbox         *mail.Outbox
+	sessionService service.SessionService
+	revocations    service.RevocationStore
+	loginLimiter   service.LoginLimiter
+	userService    service.UserService
+
+	// adminEmail, adminName and adminPassword seed the first admin.
+	adminEmail    string
+	adminName     string
+	adminPassword string
+
+	// checkinCodePeriod aligns QR refresh pushes with code rotation.
+	checkinCodePeriod time.Duration
+
+	// background scopes goroutines that live as long as the server.
+	background     context.Context
+	stopBackground context.CancelFunc
 }
 
-func NewServer(port string, logger zerolog.Logger, pool *db.Pool) *Server {
+func NewServer(cfg *config.Config, logger zerolog.Logger, pool *db.Pool, keys *jwtkeys.KeySet) (*Server, error) {
 	gin.SetMode(gin.ReleaseMode)
 
 	engine := gin.New()
-	engine.Use(gin.Recovery())
+	// Login throttling keys on the client IP, so forwarded addresses are only
+	// believed from known proxies.
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	engine.Use(gin.Recovery(), middleware.RequestID())
 
 	engine.GET("/health", func(c *gin.Context) {
 		// Check database connectivity
@@ -39,25 +80,155 @@ func NewServer(port string, logger zerolog.Logger, pool *db.Pool) *Server {
 		})
 	})
 
+	deps := newDependencies(cfg, logger, pool, keys)
+	registerRoutes(engine, deps)
+
 	httpServer := &http.Server{
-		Addr:    ":" + port,
+		Addr:    ":" + cfg.AppPort,
 		Handler: engine,
 	}
 
+	background, stopBackground := context.WithCancel(context.Background())
+
 	return &Server{
-		engine: engine,
-		http:   httpServer,
-		logger: logger,
-		pool:   pool,
+		engine:         engine,
+		http:           httpServer,
+		logger:         logger,
+		pool:           pool,
+		hub:            deps.hub,
+		outbox:         deps.outbox,
+		sessionService: deps.sessionService,
+		revocations:    deps.revocations,
+		loginLimiter:   deps.loginLimiter,
+		userService:    deps.userService,
+
+		adminEmail:    cfg.AdminEmail,
+		adminName:     cfg.AdminName,
+		adminPassword: cfg.AdminPassword,
+
+		checkinCodePeriod: cfg.CheckinCodePeriod,
+
+		background:     background,
+		stopBackground: stopBackground,
+	}, nil
+}
+
+// SeedAdmin creates the configured admin account if no active admin exists.
+func (s *Server) SeedAdmin(ctx context.Context) error {
+	if s.adminEmail == "" {
+		return nil
+	}
+
+	created, err := s.userService.SeedAdmin(ctx, s.adminEmail, s.adminName, s.adminPassword)
+	if err != nil {
+		return err
+	}
+	if created {
+		s.logger.Info().Str("email", s.adminEmail).Msg("Created admin account")
 	}
+
+	return nil
 }
 
 func (s *Server) Start() error {
 	s.logger.Info().Msg("Starting server")
+
+	go s.hub.Run(s.background)
+	go s.outbox.Run(s.background)
+	go s.sweepExpiredSessions(s.background)
+	go s.publishCheckinCodes(s.background)
+	go s.cleanupRevocations(s.background)
+	go s.cleanupLoginThrottles(s.background)
+
 	return s.http.ListenAndServe()
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info().Msg("Shutting down server")
+
+	// Hijacked WebSocket connections are not tracked by http.Server,
+	// so stop the hub explicitly to disconnect them.
+	s.stopBackground()
+
 	return s.http.Shutdown(ctx)
 }
+
+// sweepExpiredSessions periodically closes sessions whose duration has elapsed.
+func (s *Server) sweepExpiredSessions(ctx context.Context) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, sessionSweepInterval)
+			if err := s.sessionService.ExpireSessions(sweepCtx); err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to expire sessions")
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// cleanupRevocations periodically forgets revocations of tokens that have expired.
+func (s *Server) cleanupRevocations(ctx context.Context) {
+	ticker := time.NewTicker(revocationCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cleanupCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if err := s.revocations.Cleanup(cleanupCtx); err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to clean up token revocations")
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// cleanupLoginThrottles periodically forgets failed sign-ins that no longer count.
+func (s *Server) cleanupLoginThrottles(ctx context.Context) {
+	ticker := time.NewTicker(loginThrottleCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cleanupCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if err := s.loginLimiter.Cleanup(cleanupCtx); err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to clean up login throttles")
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishCheckinCodes pushes fresh check-in codes to teachers at the start of every rotation window.
+func (s *Server) publishCheckinCodes(ctx context.Context) {
+	for {
+		// Windows are counted from the Unix epoch, matching service.CheckinCodes.
+		period := int64(s.checkinCodePeriod)
+		now := time.Now().UnixNano()
+		next := (now/period + 1) * period
+
+		timer := time.NewTimer(time.Duration(next - now))
+		select {
+		case <-timer.C:
+			publishCtx, cancel := context.WithTimeout(ctx, s.checkinCodePeriod)
+			if err := s.sessionService.PublishCheckinCodes(publishCtx); err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to publish check-in codes")
+			}
+			cancel()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+)
+
+type AttendanceService interface {
+	MarkAttendance(ctx context.Context, classID, studentID uuid.UUID, input *models.MarkAttendanceInput) (*models.Attendance, error)
+	GetSessionAttendance(ctx context.Context, teacherID, classID, sessionID uuid.UUID) ([]models.StudentAttendance, error)
+	GetStudentAttendance(ctx context.Context, classID, studentID uuid.UUID) ([]models.Attendance, error)
+	GetGeofenceViolations(ctx context.Context, teacherID, classID uuid.UUID) ([]models.GeofenceViolationWithStudent, error)
+	SetAttendance(
+		ctx context.Context, teacherID, classID, sessionID, studentID uuid.UUID, input *models.SetAttendanceInput,
+	) (*models.Attendance, error)
+	BulkSetAttendance(
+		ctx context.Context, teacherID, classID, sessionID uuid.UUID, input *models.BulkSetAttendanceInput,
+	) ([]models.Attendance, error)
+}
+
+type attendanceService struct {
+	attendanceRepo repository.AttendanceRepository
+	sessionRepo    repository.SessionRepository
+	enrollmentRepo repository.EnrollmentRepository
+	classRepo      repository.ClassRepository
+	throttleRepo   repository.LoginThrottleRepository
+	authorizer     Authorizer
+	transactor     repository.Transactor
+	audit          AuditService
+	broadcaster    Broadcaster
+	checkinCodes   CheckinCodes
+}
+
+func NewAttendanceService(
+	attendanceRepo repository.AttendanceRepository,
+	sessionRepo repository.SessionRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	classRepo repository.ClassRepository,
+	throttleRepo repository.LoginThrottleRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	broadcaster Broadcaster,
+	checkinCodes CheckinCodes,
+) AttendanceService {
+	return &attendanceService{
+		attendanceRepo: attendanceRepo,
+		sessionRepo:    sessionRepo,
+		enrollmentRepo: enrollmentRepo,
+		classRepo:      classRepo,
+		throttleRepo:   throttleRepo,
+		authorizer:     authorizer,
+		transactor:     transactor,
+		audit:          audit,
+		broadcaster:    broadcaster,
+		checkinCodes:   checkinCodes,
+	}
+}
+
+// MarkAttendance records a student as present in the class's open session.
+// The code must match the session's current rotating check-in code, and when
+// the class has a geofence the reported location must fall inside it. After
+// checkinMaxFailures wrong codes the student gets ErrCheckinLocked for the
+// rest of the session and must ask a teacher to mark them.
+func (s *attendanceService) MarkAttendance(
+	ctx context.Context, classID, studentID uuid.UUID, input *models.MarkAttendanceInput,
+) (*models.Attendance, error) {
+	class, err := s.classRepo.GetByID(ctx, classID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, fmt.Errorf("failed to get class: %w", err)
+	}
+
+	enrolled, err := s.enrollmentRepo.IsEnrolled(ctx, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if !enrolled {
+		return nil, ErrNotEnrolled
+	}
+
+	now := time.Now()
+	session, err := s.sessionRepo.GetOpenByClassID(ctx, classID, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNoOpenSession
+		}
+		return nil, fmt.Errorf("failed to get open session: %w", err)
+	}
+
+	if err := s.checkCode(ctx, session, studentID, input.Code, now); err != nil {
+		return nil, err
+	}
+
+	location := models.Location{
+		Latitude:       input.Latitude,
+		Longitude:      input.Longitude,
+		AccuracyMeters: input.AccuracyMeters,
+	}
+	if err := s.checkGeofence(ctx, class, session, studentID, &location, now); err != nil {
+		return nil, err
+	}
+
+	status := models.StatusPresent
+	if session.LateAt != nil && now.After(*session.LateAt) {
+		status = models.StatusLate
+	}
+
+	attendance := &models.Attendance{
+		ID:        uuid.New(),
+		ClassID:   classID,
+		SessionID: session.ID,
+		StudentID: studentID,
+		Status:    status,
+		Location:  location,
+		MarkedAt:  now,
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// The unique (session_id, student_id) key rejects concurrent duplicates.
+		if err := s.attendanceRepo.Create(ctx, attendance); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrAlreadyMarked
+			}
+			return fmt.Errorf("failed to create attendance: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditAttendanceMarked,
+			EntityType: models.EntityAttendance,
+			EntityID:   attendance.ID,
+			After:      attendance.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only announce the mark once the row is committed.
+	s.broadcaster.Broadcast(classID, models.RoleTeacher, EventAttendanceMarked, attendance.ToResponse())
+
+	return attendance, nil
+}
+
+// checkCode verifies a submitted check-in code, counting wrong ones against
+// the student so the code cannot be guessed.
+func (s *attendanceService) checkCode(
+	ctx context.Context, session *models.ClassSession, studentID uuid.UUID, code string, now time.Time,
+) error {
+	key := checkinKey(session.ID, studentID)
+	throttles, err := s.throttleRepo.GetMany(ctx, []string{key})
+	if err != nil {
+		return err
+	}
+	for i := range throttles {
+		if throttles[i].IsBlocked(now) {
+			return ErrCheckinLocked
+		}
+	}
+
+	if s.checkinCodes.Verify(session.Secret, code, now) {
+		return nil
+	}
+
+	// Failures count for the whole session.
+	t, err := s.throttleRepo.RecordFailure(ctx, key, now, session.ExpiresAt.Sub(session.OpenedAt))
+	if err != nil {
+		return err
+	}
+	if t.Failures >= checkinMaxFailures {
+		if err := s.throttleRepo.Block(ctx, key, session.ExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return ErrInvalidCheckinCode
+}
+
+func checkinKey(sessionID, studentID uuid.UUID) string {
+	return "checkin:" + sessionID.String() + ":" + studentID.String()
+}
+
+// GetSessionAttendance returns the students who attended a session (requires attendance:view).
+func (s *attendanceService) GetSessionAttendance(
+	ctx context.Context, teacherID, classID, sessionID uuid.UUID,
+) ([]models.StudentAttendance, error) {
+	if _, err := s.getAuthorizedSession(ctx, teacherID, classID, sessionID, models.PermAttendanceView); err != nil {
+		return nil, err
+	}
+
+	records, err := s.attendanceRepo.GetStudentsWithDetailsBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session attendance: %w", err)
+	}
+
+	return records, nil
+}
+
+// GetStudentAttendance returns a student's attendance history for a class.
+func (s *attendanceService) GetStudentAttendance(
+	ctx context.Context, classID, studentID uuid.UUID,
+) ([]models.Attendance, error) {
+	enrolled, err := s.enrollmentRepo.IsEnrolled(ctx, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if !enrolled {
+		return nil, ErrNotEnrolled
+	}
+
+	records, err := s.attendanceRepo.GetByStudentID(ctx, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student attendance: %w", err)
+	}
+
+	return records, nil
+}
+
+// SetAttendance overrides one enrolled student's status for a session (requires attendance:override).
+func (s *attendanceService) SetAttendance(
+	ctx context.Context, teacherID, classID, sessionID, studentID uuid.UUID, input *models.SetAttendanceInput,
+) (*models.Attendance, error) {
+	if _, err := s.getAuthorizedSession(ctx, teacherID, classID, sessionID, models.PermAttendanceOverride); err != nil {
+		return nil, err
+	}
+
+	enrolled, err := s.enrollmentRepo.IsEnrolled(ctx, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if !enrolled {
+		return nil, ErrNotEnrolled
+	}
+
+	var attendance *models.Attendance
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		attendance, err = s.override(ctx, teacherID, classID, sessionID, studentID, input.Status, input.Reason, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.broadcaster.Broadcast(classID, models.RoleTeacher, EventAttendanceUpdated, attendance.ToResponse())
+
+	return attendance, nil
+}
+
+// BulkSetAttendance applies a whole roster of overrides for a session in one
+// transaction (requires attendance:override). Either every record is applied or none is.
+func (s *attendanceService) BulkSetAttendance(
+	ctx context.Context, teacherID, classID, sessionID uuid.UUID, input *models.BulkSetAttendanceInput,
+) ([]models.Attendance, error) {
+	if _, err := s.getAuthorizedSession(ctx, teacherID, classID, sessionID, models.PermAttendanceOverride); err != nil {
+		return nil, err
+	}
+
+	enrollments, err := s.enrollmentRepo.GetByClassID(ctx, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollments: %w", err)
+	}
+
+	enrolled := make(map[uuid.UUID]bool, len(enrollments))
+	for _, e := range enrollments {
+		enrolled[e.StudentID] = true
+	}
+
+	seen := make(map[uuid.UUID]bool, len(input.Records))
+	for _, record := range input.Records {
+		if !enrolled[record.StudentID] {
+			return nil, fmt.Errorf("%w: %s", ErrNotEnrolled, record.StudentID)
+		}
+		if seen[record.StudentID] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateRosterEntry, record.StudentID)
+		}
+		seen[record.StudentID] = true
+	}
+
+	now := time.Now()
+	records := make([]models.Attendance, 0, len(input.Records))
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for _, record := range input.Records {
+			attendance, err := s.override(
+				ctx, teacherID, classID, sessionID, record.StudentID, record.Status, record.Reason, now,
+			)
+			if err != nil {
+				return err
+			}
+			records = append(records, *attendance)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		s.broadcaster.Broadcast(classID, models.RoleTeacher, EventAttendanceUpdated, records[i].ToResponse())
+	}
+
+	return records, nil
+}
+
+// override writes a status change and its history entry. Must run inside a transaction.
+func (s *attendanceService) override(
+	ctx context.Context,
+	teacherID, classID, sessionID, studentID uuid.UUID,
+	status models.AttendanceStatus,
+	reason string,
+	now time.Time,
+) (*models.Attendance, error) {
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+
+	attendance := &models.Attendance{
+		ID:           uuid.New(),
+		ClassID:      classID,
+		SessionID:    sessionID,
+		StudentID:    studentID,
+		Status:       status,
+		Reason:       reasonPtr,
+		MarkedAt:     now,
+		OverriddenBy: &teacherID,
+		OverriddenAt: &now,
+	}
+
+	previous, err := s.attendanceRepo.Override(ctx, attendance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to override attendance: %w", err)
+	}
+
+	override := &models.AttendanceOverride{
+		ID:             uuid.New(),
+		AttendanceID:   attendance.ID,
+		PreviousStatus: previous,
+		NewStatus:      status,
+		Reason:         reasonPtr,
+		ChangedBy:      teacherID,
+		ChangedAt:      now,
+	}
+	if err := s.attendanceRepo.CreateOverride(ctx, override); err != nil {
+		return nil, fmt.Errorf("failed to record override: %w", err)
+	}
+
+	var before interface{}
+	if previous != nil {
+		before = map[string]models.AttendanceStatus{"status": *previous}
+	}
+	if err := s.audit.Record(ctx, AuditEntry{
+		Action:     models.AuditAttendanceOverride,
+		EntityType: models.EntityAttendance,
+		EntityID:   attendance.ID,
+		Before:     before,
+		After:      attendance.ToResponse(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return attendance, nil
+}
+
+// getAuthorizedSession loads a session of a class the user holds perm in.
+func (s *attendanceService) getAuthorizedSession(
+	ctx context.Context, userID, classID, sessionID uuid.UUID, perm models.Permission,
+) (*models.ClassSession, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, perm); err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.ClassID != classID {
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+// GetGeofenceViolations returns out-of-range marking attempts for a class (requires attendance:view).
+func (s *attendanceService) GetGeofenceViolations(
+	ctx context.Context, teacherID, classID uuid.UUID,
+) ([]models.GeofenceViolationWithStudent, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermAttendanceView); err != nil {
+		return nil, err
+	}
+
+	violations, err := s.attendanceRepo.GetGeofenceViolationsByClassID(ctx, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence violations: %w", err)
+	}
+
+	return violations, nil
+}
+
+// checkGeofence fills in the distance from the class centre and rejects
+// marks outside the radius, recording the attempt for the teacher to review.
+func (s *attendanceService) checkGeofence(
+	ctx context.Context,
+	class *models.Class,
+	session *models.ClassSession,
+	studentID uuid.UUID,
+	location *models.Location,
+	now time.Time,
+) error {
+	fence := class.Geofence
+	if fence.Latitude == nil || fence.Longitude == nil || location.Latitude == nil || location.Longitude == nil {
+		if fence.Enabled {
+			return ErrLocationRequired
+		}
+		return nil
+	}
+
+	distance := haversineMeters(*fence.Latitude, *fence.Longitude, *location.Latitude, *location.Longitude)
+	location.DistanceMeters = &distance
+
+	if !fence.Enabled || fence.RadiusMeters == nil || distance <= float64(*fence.RadiusMeters) {
+		return nil
+	}
+
+	violation := &models.GeofenceViolation{
+		ID:             uuid.New(),
+		ClassID:        class.ID,
+		SessionID:      session.ID,
+		StudentID:      studentID,
+		Latitude:       *location.Latitude,
+		Longitude:      *location.Longitude,
+		AccuracyMeters: location.AccuracyMeters,
+		DistanceMeters: distance,
+		AttemptedAt:    now,
+	}
+	if err := s.attendanceRepo.CreateGeofenceViolation(ctx, violation); err != nil {
+		return fmt.Errorf("failed to record geofence violation: %w", err)
+	}
+
+	return ErrOutsideGeofence
+}
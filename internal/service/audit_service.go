@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// AuditEntry describes one state change to record.
+// Before and After are marshalled to JSON; nil is stored as NULL.
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	Before     interface{}
+	After      interface{}
+	// Actor overrides the request's authenticated user, e.g. for registration
+	// where the new user acts before holding a token.
+	Actor *requestctx.Actor
+}
+
+type AuditService interface {
+	// Record appends an event. Call it with the context of the transaction
+	// making the change so the event commits or rolls back with it.
+	Record(ctx context.Context, entry AuditEntry) error
+	ListEvents(ctx context.Context, filter *models.AuditEventFilter) (*models.AuditEventPage, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) Record(ctx context.Context, entry AuditEntry) error {
+	before, err := marshalAuditState(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditState(entry.After)
+	if err != nil {
+		return err
+	}
+
+	event := &models.AuditEvent{
+		ID:         uuid.New(),
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		Before:     before,
+		After:      after,
+		CreatedAt:  time.Now(),
+	}
+	if entry.EntityID != uuid.Nil {
+		event.EntityID = &entry.EntityID
+	}
+
+	actor, ok := requestctx.ActorFrom(ctx)
+	if entry.Actor != nil {
+		actor, ok = *entry.Actor, true
+	}
+	if ok {
+		event.ActorID = &actor.UserID
+		event.ActorRole = &actor.Role
+	}
+
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		event.RequestID = &requestID
+	}
+
+	if err := s.auditRepo.Create(ctx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents returns one page of the audit log, newest first.
+func (s *auditService) ListEvents(
+	ctx context.Context, filter *models.AuditEventFilter,
+) (*models.AuditEventPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	events, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	page := &models.AuditEventPage{
+		Events: events,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if len(events) == filter.Limit {
+		next := filter.Offset + filter.Limit
+		page.NextOffset = &next
+	}
+
+	return page, nil
+}
+
+func marshalAuditState(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit state: %w", err)
+	}
+
+	return data, nil
+}
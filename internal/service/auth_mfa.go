@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+)
+
+// mfaMaxAttempts is how many wrong codes a login challenge tolerates
+// before the user has to enter their password again.
+const mfaMaxAttempts = 5
+
+func (s *authService) EnrollMFA(ctx context.Context, userID uuid.UUID) (*models.MFAEnrollmentResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := generateSecret(totpSecretSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	mfa := &models.UserMFA{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	if err := s.mfaRepo.SavePending(ctx, mfa); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	return &models.MFAEnrollmentResponse{
+		Secret:     totpEncoding.EncodeToString(secret),
+		OTPAuthURI: totpURI(s.config.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor authentication once the user proves their
+// authenticator works. Sessions signed in without it are ended.
+func (s *authService) ConfirmMFA(
+	ctx context.Context, userID uuid.UUID, code string,
+) (*models.MFAConfirmResponse, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashToken(c)
+	}
+
+	var response *models.MFAConfirmResponse
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrMFASetupNotStarted
+			}
+			return err
+		}
+		if mfa.IsConfirmed() {
+			return ErrMFAAlreadyEnabled
+		}
+
+		now := time.Now()
+		step, ok := verifyTOTP(mfa.Secret, code, mfa.LastUsedStep, now)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		if err := s.mfaRepo.Confirm(ctx, userID, now); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrMFAAlreadyEnabled
+			}
+			return err
+		}
+		if err := s.mfaRepo.UseStep(ctx, userID, step); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+			return err
+		}
+		if err := s.RevokeSessions(ctx, userID); err != nil {
+			return err
+		}
+
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		tokens, _, err := s.issueTokens(ctx, user, uuid.New(), true)
+		if err != nil {
+			return err
+		}
+		response = &models.MFAConfirmResponse{RecoveryCodes: codes, Tokens: *tokens}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditMFAEnabled,
+			EntityType: models.EntityUser,
+			EntityID:   userID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// VerifyMFA redeems a login challenge with a TOTP or recovery code.
+// Wrong codes count against the challenge, which stops working after
+// mfaMaxAttempts, and against the account like wrong passwords, so new
+// challenges cannot be used to brute force the six-digit space.
+func (s *authService) VerifyMFA(
+	ctx context.Context, input *models.VerifyMFAInput, clientIP string,
+) (*models.AuthResponse, error) {
+	var (
+		response *models.AuthResponse
+		user     *models.User
+		failed   bool
+	)
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		challenge, err := s.mfaRepo.GetChallengeByHashForUpdate(ctx, hashToken(input.MFAToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidMFAChallenge
+			}
+			return err
+		}
+		if challenge.UsedAt != nil || !now.Before(challenge.ExpiresAt) || challenge.Attempts >= mfaMaxAttempts {
+			return ErrInvalidMFAChallenge
+		}
+
+		user, err = s.userRepo.GetByID(ctx, challenge.UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidMFAChallenge
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.IsDisabled() {
+			return ErrInvalidMFAChallenge
+		}
+		if err := s.limiter.Check(ctx, user.Email, clientIP); err != nil {
+			if errors.Is(err, ErrAccountLocked) {
+				return ErrInvalidMFAChallenge
+			}
+			return err
+		}
+
+		ok, err := s.checkSecondFactor(ctx, user, input, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Commit the attempt, then report the wrong code.
+			failed = true
+			return s.mfaRepo.IncrementChallengeAttempts(ctx, challenge.ID)
+		}
+
+		if err := s.mfaRepo.MarkChallengeUsed(ctx, challenge.ID, now); err != nil {
+			return err
+		}
+
+		response, _, err = s.issueTokens(ctx, user, uuid.New(), true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		lockedUntil, err := s.limiter.RecordFailure(ctx, user.Email, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		if lockedUntil != nil {
+			if err := s.lockAccount(ctx, user, *lockedUntil); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.limiter.Reset(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// checkSecondFactor verifies and consumes a TOTP or recovery code.
+func (s *authService) checkSecondFactor(
+	ctx context.Context, user *models.User, input *models.VerifyMFAInput, now time.Time,
+) (bool, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, ErrInvalidMFAChallenge
+		}
+		return false, err
+	}
+	if !mfa.IsConfirmed() {
+		return false, ErrInvalidMFAChallenge
+	}
+
+	if input.RecoveryCode != "" {
+		codeHash := hashToken(normalizeRecoveryCode(input.RecoveryCode))
+		if err := s.mfaRepo.UseRecoveryCode(ctx, user.ID, codeHash, now); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		// The request is unauthenticated, so the user is their own actor.
+		return true, s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditMFARecoveryCodeUsed,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+			Actor:      &requestctx.Actor{UserID: user.ID, Role: user.Role},
+		})
+	}
+
+	step, ok := verifyTOTP(mfa.Secret, input.Code, mfa.LastUsedStep, now)
+	if !ok {
+		return false, nil
+	}
+	if err := s.mfaRepo.UseStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DisableMFA turns off two-factor authentication after checking a current
+// code. Roles the policy requires it for cannot turn it off.
+func (s *authService) DisableMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if s.mfaRequired(user.Role) {
+		return ErrMFARequired
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrMFANotEnabled
+			}
+			return err
+		}
+		if !mfa.IsConfirmed() {
+			return ErrMFANotEnabled
+		}
+
+		step, ok := verifyTOTP(mfa.Secret, code, mfa.LastUsedStep, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		// Spend the code so it cannot be replayed, as for a login.
+		if err := s.mfaRepo.UseStep(ctx, userID, step); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+
+		if err := s.mfaRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditMFADisabled,
+			EntityType: models.EntityUser,
+			EntityID:   userID,
+		})
+	})
+}
+
+func (s *authService) MFASatisfied(claims *Claims) bool {
+	return !s.mfaRequired(claims.Role) || claims.HasMFA()
+}
+
+func (s *authService) mfaRequired(role models.Role) bool {
+	return slices.Contains(s.config.MFARequiredRoles, role)
+}
+
+// createMFAChallenge answers a correct password for an account with
+// two-factor authentication. No tokens are issued until VerifyMFA.
+func (s *authService) createMFAChallenge(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &models.MFAChallenge{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.config.MFAChallengeTTL),
+	}
+	if err := s.mfaRepo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		MFAChallenge: &models.MFAChallengeResponse{
+			MFAToken:  token,
+			ExpiresAt: challenge.ExpiresAt,
+		},
+		User: user.ToResponse(),
+	}, nil
+}
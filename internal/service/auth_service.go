@@ -4,42 +4,192 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/jwtkeys"
+	"github.com/tahiriqbal095/attendify/internal/mail"
 	"github.com/tahiriqbal095/attendify/internal/models"
 	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const bcryptCost = 12
+
+// VerificationPolicy decides what an account may do before its email is verified.
+type VerificationPolicy string
+
+const (
+	// VerifyNone places no restrictions on unverified accounts.
+	VerifyNone VerificationPolicy = "none"
+	// VerifyBeforeEnrollment lets unverified students sign in but not join classes.
+	VerifyBeforeEnrollment VerificationPolicy = "enrollment"
+	// VerifyBeforeLogin refuses to sign in unverified accounts at all.
+	VerifyBeforeLogin VerificationPolicy = "login"
+)
+
+func (p VerificationPolicy) BlocksLogin() bool {
+	return p == VerifyBeforeLogin
+}
+
+func (p VerificationPolicy) BlocksEnrollment() bool {
+	return p == VerifyBeforeEnrollment || p == VerifyBeforeLogin
+}
+
+const (
+	// verificationResendInterval is the minimum gap between verification emails.
+	verificationResendInterval = time.Minute
+	// verificationMaxPerHour caps verification emails per account per hour.
+	verificationMaxPerHour = 5
+)
+
+// AuthConfig sets the lifetimes of issued credentials and the links emailed to users.
+type AuthConfig struct {
+	// AccessTTL is how long a JWT access token is valid. Keep it short:
+	// clients renew it with their refresh token.
+	AccessTTL time.Duration
+	// RefreshTTL is how long a refresh token can be exchanged.
+	RefreshTTL time.Duration
+	// PasswordResetTTL is how long an emailed reset link works.
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the frontend page that receives the reset token.
+	PasswordResetURL string
+	// EmailVerificationTTL is how long an emailed verification link works.
+	EmailVerificationTTL time.Duration
+	// EmailVerificationURL is the frontend page that receives the verification token.
+	EmailVerificationURL string
+	// VerificationPolicy is what unverified accounts are barred from.
+	VerificationPolicy VerificationPolicy
+	// UnlockURL is the frontend page that receives the account unlock token.
+	UnlockURL string
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+	// MFAChallengeTTL is how long a user has to enter their code after the password.
+	MFAChallengeTTL time.Duration
+	// MFARequiredRoles must sign in with a second factor; other roles may opt in.
+	MFARequiredRoles []models.Role
+}
+
+// Authentication methods recorded in the amr claim (RFC 8176).
 const (
-	bcryptCost  = 12
-	tokenExpiry = 24 * time.Hour
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
 )
 
+// Token times carry microseconds, matching Postgres timestamps, so a
+// revocation cutoff separates tokens issued just before it from the ones
+// issued just after.
+func init() {
+	jwt.TimePrecision = time.Microsecond
+}
+
 // Claims represents the JWT payload.
 type Claims struct {
 	UserID uuid.UUID   `json:"user_id"`
 	Role   models.Role `json:"role"`
+	AMR    []string    `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasMFA reports whether the token was issued after a second factor.
+func (c *Claims) HasMFA() bool {
+	return slices.Contains(c.AMR, AMROTP)
+}
+
+// TokenID returns the jti, which ValidateToken guarantees is a UUID.
+func (c *Claims) TokenID() uuid.UUID {
+	id, _ := uuid.Parse(c.ID)
+	return id
+}
+
 type AuthService interface {
 	Register(ctx context.Context, input *models.RegisterInput) (*models.User, error)
-	Login(ctx context.Context, input *models.LoginInput) (*models.AuthResponse, error)
+	// Login checks a password. Failures are counted against the account and
+	// clientIP, and every refusal looks the same to the caller except
+	// ErrLoginThrottled, which depends only on the client.
+	Login(ctx context.Context, input *models.LoginInput, clientIP string) (*models.AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
+	// Logout revokes the access token and, if given, the refresh token family.
+	Logout(ctx context.Context, claims *Claims, refreshToken string) error
+	// RequestPasswordReset emails a reset link if the account exists. It
+	// behaves the same either way so callers cannot probe for accounts.
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, input *models.ConfirmPasswordResetInput) error
+	// UnlockAccount redeems the link emailed when an account was locked.
+	UnlockAccount(ctx context.Context, token string) error
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerification emails a new verification link to an unverified
+	// account. Like RequestPasswordReset it does not reveal whether one exists.
+	ResendVerification(ctx context.Context, email string) error
 	ValidateToken(tokenString string) (*Claims, error)
+	// RevokeSessions signs a user out everywhere: refresh tokens stop
+	// working and access tokens already issued are rejected.
+	RevokeSessions(ctx context.Context, userID uuid.UUID) error
+
+	// EnrollMFA starts TOTP enrollment. It has no effect on logins until
+	// ConfirmMFA proves the authenticator app produces matching codes.
+	EnrollMFA(ctx context.Context, userID uuid.UUID) (*models.MFAEnrollmentResponse, error)
+	// ConfirmMFA enables two-factor authentication, returning recovery codes
+	// and tokens that satisfy the MFA policy.
+	ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) (*models.MFAConfirmResponse, error)
+	// VerifyMFA completes a login that returned an MFA challenge. Wrong
+	// codes count toward the same lockout as wrong passwords.
+	VerifyMFA(ctx context.Context, input *models.VerifyMFAInput, clientIP string) (*models.AuthResponse, error)
+	DisableMFA(ctx context.Context, userID uuid.UUID, code string) error
+	// MFASatisfied reports whether the token meets the MFA policy for its role.
+	MFASatisfied(claims *Claims) bool
 }
 
 type authService struct {
-	userRepo  repository.UserRepository
-	jwtSecret []byte
+	userRepo          repository.UserRepository
+	refreshTokenRepo  repository.RefreshTokenRepository
+	passwordResetRepo repository.PasswordResetRepository
+	verificationRepo  repository.EmailVerificationRepository
+	mfaRepo           repository.MFARepository
+	unlockRepo        repository.AccountUnlockRepository
+	limiter           LoginLimiter
+	revocations       RevocationStore
+	transactor        repository.Transactor
+	audit             AuditService
+	mailer            mail.Mailer
+	keys              *jwtkeys.KeySet
+	config            AuthConfig
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtSecret string) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	verificationRepo repository.EmailVerificationRepository,
+	mfaRepo repository.MFARepository,
+	unlockRepo repository.AccountUnlockRepository,
+	limiter LoginLimiter,
+	revocations RevocationStore,
+	transactor repository.Transactor,
+	audit AuditService,
+	mailer mail.Mailer,
+	keys *jwtkeys.KeySet,
+	config AuthConfig,
+) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		passwordResetRepo: passwordResetRepo,
+		verificationRepo:  verificationRepo,
+		mfaRepo:           mfaRepo,
+		unlockRepo:        unlockRepo,
+		limiter:           limiter,
+		revocations:       revocations,
+		transactor:        transactor,
+		audit:             audit,
+		mailer:            mailer,
+		keys:              keys,
+		config:            config,
 	}
 }
 
@@ -51,79 +201,597 @@ func (s *authService) Register(ctx context.Context, input *models.RegisterInput)
 
 	user := &models.User{
 		ID:           uuid.New(),
-		Email:        input.Email,
+		Email:        models.NormalizeEmail(input.Email),
 		PasswordHash: string(hashedPassword),
 		Name:         input.Name,
 		Role:         input.Role,
 		CreatedAt:    time.Now(),
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		if errors.Is(err, repository.ErrDuplicateKey) {
-			return nil, ErrEmailTaken
+	var verificationToken string
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrEmailTaken
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		var err error
+		if verificationToken, err = s.createVerificationToken(ctx, user.ID); err != nil {
+			return err
 		}
-		return nil, fmt.Errorf("failed to create user: %w", err)
+
+		// The request is unauthenticated, so the new user is its own actor.
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditUserRegistered,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+			After:      user.ToResponse(),
+			Actor:      &requestctx.Actor{UserID: user.ID, Role: user.Role},
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	// The account exists either way; if the email cannot be queued the user
+	// can ask for it again through ResendVerification.
+	_ = s.sendVerification(ctx, user, verificationToken)
+
 	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, input *models.LoginInput) (*models.AuthResponse, error) {
-	user, err := s.userRepo.GetByEmail(ctx, input.Email)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
+func (s *authService) Login(
+	ctx context.Context, input *models.LoginInput, clientIP string,
+) (*models.AuthResponse, error) {
+	if err := s.limiter.Check(ctx, input.Email, clientIP); err != nil {
+		// A locked account answers like a wrong password, so lockouts
+		// reveal neither the account nor whether the password was right.
+		if errors.Is(err, ErrAccountLocked) {
 			return nil, ErrInvalidCredentials
 		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+	if !checkPassword(user, input.Password) {
+		lockedUntil, err := s.limiter.RecordFailure(ctx, input.Email, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		if lockedUntil != nil && user != nil {
+			if err := s.lockAccount(ctx, user, *lockedUntil); err != nil {
+				return nil, err
+			}
+		}
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.generateToken(user)
+	// Only reported after the password matched, so these reveal nothing to a guesser.
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	if s.config.VerificationPolicy.BlocksLogin() && !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+	if mfa != nil && mfa.IsConfirmed() {
+		// Failures are only forgotten once the second factor passes too.
+		return s.createMFAChallenge(ctx, user)
+	}
+
+	if err := s.limiter.Reset(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	// Each login starts a new refresh token family.
+	response, _, err := s.issueTokens(ctx, user, uuid.New(), false)
+	return response, err
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Each refresh token works once; presenting a used one means it leaked, so
+// every token descended from the same login is revoked.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	var (
+		response *models.AuthResponse
+		reused   bool
+	)
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		current, err := s.refreshTokenRepo.GetByHashForUpdate(ctx, hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if current.UsedAt != nil {
+			// Commit the revocation, then report the reuse.
+			reused = true
+			if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID, now); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, AuditEntry{
+				Action:     models.AuditRefreshTokenReused,
+				EntityType: models.EntityUser,
+				EntityID:   current.UserID,
+				After:      map[string]uuid.UUID{"family_id": current.FamilyID},
+			})
+		}
+
+		if !now.Before(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		user, err := s.userRepo.GetByID(ctx, current.UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.IsDisabled() {
+			return ErrInvalidRefreshToken
+		}
+
+		var next *models.RefreshToken
+		response, next, err = s.issueTokens(ctx, user, current.FamilyID, current.MFA)
+		if err != nil {
+			return err
+		}
+		if err := s.refreshTokenRepo.MarkUsed(ctx, current.ID, next.ID, now); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  user.ToResponse(),
-	}, nil
+	return response, nil
 }
 
-func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+func (s *authService) Logout(ctx context.Context, claims *Claims, refreshToken string) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.revocations.Revoke(ctx, claims.TokenID(), claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return err
 		}
-		return s.jwtSecret, nil
+
+		if refreshToken != "" {
+			current, err := s.refreshTokenRepo.GetByHashForUpdate(ctx, hashToken(refreshToken))
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			// Ignore unknown tokens and tokens belonging to someone else.
+			if current != nil && current.UserID == claims.UserID {
+				if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID, time.Now()); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditUserLoggedOut,
+			EntityType: models.EntityUser,
+			EntityID:   claims.UserID,
+		})
 	})
+}
+
+func (s *authService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reset := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(s.config.PasswordResetTTL),
+		CreatedAt: now,
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Only the newest link works.
+		if err := s.passwordResetRepo.MarkUsedByUserID(ctx, user.ID, now); err != nil {
+			return err
+		}
+		if err := s.passwordResetRepo.Create(ctx, reset); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditPasswordResetRequested,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.config.PasswordResetURL + "?" + url.Values{"token": {token}}.Encode()
+	if err := s.mailer.Send(ctx, passwordResetMessage(user, link, s.config.PasswordResetTTL)); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmPasswordReset redeems a reset token, sets the new password and
+// signs the user out everywhere.
+func (s *authService) ConfirmPasswordReset(ctx context.Context, input *models.ConfirmPasswordResetInput) error {
+	// Hash before taking row locks; bcrypt is deliberately slow.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcryptCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		reset, err := s.passwordResetRepo.GetByHashForUpdate(ctx, hashToken(input.Token))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if reset.UsedAt != nil || !now.Before(reset.ExpiresAt) {
+			return ErrInvalidResetToken
+		}
+
+		if err := s.userRepo.UpdatePassword(ctx, reset.UserID, string(hashedPassword)); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if err := s.passwordResetRepo.MarkUsedByUserID(ctx, reset.UserID, now); err != nil {
+			return err
+		}
+		// The link went to the account's address, which proves it; this is
+		// how accounts created by roster imports become verified.
+		if err := s.userRepo.MarkEmailVerified(ctx, reset.UserID, now); err != nil {
+			return err
+		}
+		if err := s.RevokeSessions(ctx, reset.UserID); err != nil {
+			return err
+		}
+		// Proving control of the mailbox also lifts a lockout.
+		if err := s.unlock(ctx, reset.UserID, now); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditPasswordReset,
+			EntityType: models.EntityUser,
+			EntityID:   reset.UserID,
+		})
+	})
+}
+
+func (s *authService) UnlockAccount(ctx context.Context, token string) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		unlock, err := s.unlockRepo.GetByHashForUpdate(ctx, hashToken(token))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidUnlockToken
+			}
+			return err
+		}
+		if unlock.UsedAt != nil || !now.Before(unlock.ExpiresAt) {
+			return ErrInvalidUnlockToken
+		}
+
+		if err := s.unlock(ctx, unlock.UserID, now); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditAccountUnlocked,
+			EntityType: models.EntityUser,
+			EntityID:   unlock.UserID,
+		})
+	})
+}
+
+// lockAccount records a lockout and emails the user a link to lift it early.
+func (s *authService) lockAccount(ctx context.Context, user *models.User, until time.Time) error {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	unlock := &models.AccountUnlockToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: until,
+		CreatedAt: now,
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Only the newest link works.
+		if err := s.unlockRepo.MarkUsedByUserID(ctx, user.ID, now); err != nil {
+			return err
+		}
+		if err := s.unlockRepo.Create(ctx, unlock); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditAccountLocked,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+			After:      map[string]time.Time{"locked_until": until},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// The lockout holds either way; without the email it simply runs its course.
+	link := s.config.UnlockURL + "?" + url.Values{"token": {token}}.Encode()
+	_ = s.mailer.Send(ctx, accountLockedMessage(user, link, until.Sub(now)))
+
+	return nil
+}
+
+// unlock clears a user's failed sign-ins and consumes their unlock links.
+func (s *authService) unlock(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidUnlockToken
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.unlockRepo.MarkUsedByUserID(ctx, userID, now); err != nil {
+		return err
+	}
+	return s.limiter.Reset(ctx, user.Email)
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		verification, err := s.verificationRepo.GetByHashForUpdate(ctx, hashToken(token))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		if verification.UsedAt != nil || !now.Before(verification.ExpiresAt) {
+			return ErrInvalidVerificationToken
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, verification.UserID, now); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		if err := s.verificationRepo.MarkUsedByUserID(ctx, verification.UserID, now); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEmailVerified,
+			EntityType: models.EntityUser,
+			EntityID:   verification.UserID,
+		})
+	})
+}
+
+func (s *authService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	recent, err := s.verificationRepo.CountCreatedSince(ctx, user.ID, now.Add(-verificationResendInterval))
+	if err != nil {
+		return err
+	}
+	hourly, err := s.verificationRepo.CountCreatedSince(ctx, user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent > 0 || hourly >= verificationMaxPerHour {
+		return ErrVerificationThrottled
+	}
+
+	var token string
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Only the newest link works.
+		if err := s.verificationRepo.MarkUsedByUserID(ctx, user.ID, now); err != nil {
+			return err
+		}
+		token, err = s.createVerificationToken(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sendVerification(ctx, user, token)
+}
+
+func (s *authService) createVerificationToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	verification := &models.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(s.config.EmailVerificationTTL),
+		CreatedAt: now,
+	}
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *authService) sendVerification(ctx context.Context, user *models.User, token string) error {
+	link := s.config.EmailVerificationURL + "?" + url.Values{"token": {token}}.Encode()
+	if err := s.mailer.Send(ctx, emailVerificationMessage(user, link, s.config.EmailVerificationTTL)); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+func (s *authService) RevokeSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.refreshTokenRepo.RevokeByUserID(ctx, userID, time.Now()); err != nil {
+		return err
+	}
+	return s.revocations.RevokeUser(ctx, userID)
+}
+
+func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keys.Keyfunc)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens without a jti cannot be revoked, so they are not accepted.
+	if _, err := uuid.Parse(claims.ID); err != nil {
 		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
 }
 
-func (s *authService) generateToken(user *models.User) (string, error) {
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// checkPassword compares against a dummy hash when user is nil, so unknown
+// emails take as long to reject as wrong passwords.
+func checkPassword(user *models.User, password string) bool {
+	if user == nil {
+		dummyPasswordHashOnce.Do(func() {
+			dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("attendify-dummy-password"), bcryptCost)
+		})
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+// issueTokens signs an access token and stores a new refresh token in the
+// family. mfa records that the login passed a second factor.
+func (s *authService) issueTokens(
+	ctx context.Context, user *models.User, familyID uuid.UUID, mfa bool,
+) (*models.AuthResponse, *models.RefreshToken, error) {
 	now := time.Now()
+	accessExpiresAt := now.Add(s.config.AccessTTL)
+	accessToken, err := s.generateToken(user, now, accessExpiresAt, mfa)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, refreshHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: now.Add(s.config.RefreshTTL),
+		CreatedAt: now,
+		MFA:       mfa,
+	}
+	if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+		return nil, nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:            accessToken,
+		ExpiresAt:        &accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: &stored.ExpiresAt,
+		User:             user.ToResponse(),
+	}, stored, nil
+}
+
+func (s *authService) generateToken(user *models.User, now, expiresAt time.Time, mfa bool) (string, error) {
+	amr := []string{AMRPassword}
+	if mfa {
+		amr = append(amr, AMROTP)
+	}
+
 	claims := &Claims{
 		UserID: user.ID,
 		Role:   user.Role,
+		AMR:    amr,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(tokenExpiry)),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Subject:   user.ID.String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return s.keys.Sign(claims)
 }
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+)
+
+// Authorizer decides what a user may do in a class. It is the one place
+// class ownership is checked: services ask for a permission rather than
+// comparing teacher IDs.
+type Authorizer interface {
+	// AuthorizeClass loads a class and returns ErrClassPermissionDenied
+	// unless the user holds perm in it.
+	AuthorizeClass(ctx context.Context, userID, classID uuid.UUID, perm models.Permission) (*models.Class, error)
+	// HasClassPermission reports whether the user holds perm in the class
+	// without loading it.
+	HasClassPermission(ctx context.Context, userID, classID uuid.UUID, perm models.Permission) (bool, error)
+	// ClassRole returns the user's role in the class, or "" if they have none.
+	ClassRole(ctx context.Context, userID, classID uuid.UUID) (models.ClassRole, error)
+}
+
+type authorizer struct {
+	classRepo      repository.ClassRepository
+	staffRepo      repository.ClassStaffRepository
+	enrollmentRepo repository.EnrollmentRepository
+}
+
+func NewAuthorizer(
+	classRepo repository.ClassRepository,
+	staffRepo repository.ClassStaffRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+) Authorizer {
+	return &authorizer{
+		classRepo:      classRepo,
+		staffRepo:      staffRepo,
+		enrollmentRepo: enrollmentRepo,
+	}
+}
+
+func (a *authorizer) AuthorizeClass(
+	ctx context.Context, userID, classID uuid.UUID, perm models.Permission,
+) (*models.Class, error) {
+	class, err := a.classRepo.GetByID(ctx, classID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, fmt.Errorf("failed to get class: %w", err)
+	}
+
+	ok, err := a.HasClassPermission(ctx, userID, classID, perm)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrClassPermissionDenied
+	}
+
+	return class, nil
+}
+
+func (a *authorizer) HasClassPermission(
+	ctx context.Context, userID, classID uuid.UUID, perm models.Permission,
+) (bool, error) {
+	// A global role granting a class permission grants it in every class.
+	if actor, ok := requestctx.ActorFrom(ctx); ok && actor.UserID == userID && actor.Role.Can(perm) {
+		return true, nil
+	}
+
+	role, err := a.ClassRole(ctx, userID, classID)
+	if err != nil {
+		return false, err
+	}
+
+	return role.Can(perm), nil
+}
+
+func (a *authorizer) ClassRole(ctx context.Context, userID, classID uuid.UUID) (models.ClassRole, error) {
+	role, err := a.staffRepo.GetRole(ctx, classID, userID)
+	if err == nil {
+		return role, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return "", err
+	}
+
+	enrolled, err := a.enrollmentRepo.IsEnrolled(ctx, classID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if enrolled {
+		return models.ClassRoleStudent, nil
+	}
+
+	return "", nil
+}
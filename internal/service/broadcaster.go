@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+// Real-time event types pushed to WebSocket clients.
+const (
+	EventAttendanceMarked  = "attendance.marked"
+	EventAttendanceUpdated = "attendance.updated"
+	EventSessionOpened     = "session.opened"
+	EventSessionClosed     = "session.closed"
+	EventCheckinRotated    = "checkin.rotated"
+)
+
+// Broadcaster pushes real-time events to clients watching a class.
+// An empty role addresses everyone in the class room.
+type Broadcaster interface {
+	Broadcast(classID uuid.UUID, role models.Role, eventType string, data interface{})
+	// Watching returns the classes with a connected client of role.
+	Watching(role models.Role) []uuid.UUID
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	// checkinCodeDigits is the length of a rotating check-in code.
+	checkinCodeDigits = 6
+
+	// checkinMaxFailures is how many wrong codes a student may submit in one
+	// session before self check-in is refused until the session ends. With
+	// Skew+1 valid codes in a million, guessing stays hopeless.
+	checkinMaxFailures = 5
+
+	// sessionSecretSize is the per-session HMAC key length (RFC 4226 recommends 160 bits).
+	sessionSecretSize = 20
+)
+
+// CheckinCodes generates and verifies the rotating codes students submit
+// when marking attendance. Codes are derived statelessly from the session
+// secret and the current time window, TOTP-style (RFC 6238).
+type CheckinCodes struct {
+	// Period is how long each code is displayed before rotating.
+	Period time.Duration
+	// Skew is how many previous windows are still accepted, to absorb
+	// the delay between reading the screen and submitting.
+	Skew int
+}
+
+// Generate returns the code for the window containing now and when that window ends.
+func (c CheckinCodes) Generate(secret []byte, now time.Time) (string, time.Time) {
+	counter := c.counter(now)
+	expiresAt := time.Unix(0, 0).Add(time.Duration(counter+1) * c.Period)
+	return hotp(secret, counter, checkinCodeDigits), expiresAt
+}
+
+// Verify reports whether code matches the current window or one of the
+// Skew windows before it.
+func (c CheckinCodes) Verify(secret []byte, code string, now time.Time) bool {
+	counter := c.counter(now)
+	for i := 0; i <= c.Skew; i++ {
+		expected := hotp(secret, counter-uint64(i), checkinCodeDigits)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CheckinCodes) counter(now time.Time) uint64 {
+	return uint64(now.Unix()) / uint64(c.Period/time.Second)
+}
+
+// hotp computes an RFC 4226 one-time password.
+func hotp(secret []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+func generateSecret(size int) ([]byte, error) {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc4226Secret is the test key from RFC 4226 appendix D, whose codes for
+// counters 0 to 4 are 755224, 287082, 359152, 969429 and 338314.
+var rfc4226Secret = []byte("12345678901234567890")
+
+func TestCheckinCodesGenerate(t *testing.T) {
+	codes := CheckinCodes{Period: 30 * time.Second, Skew: 1}
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		wantCode      string
+		wantExpiresAt time.Time
+	}{
+		{"first window", time.Unix(0, 0), "755224", time.Unix(30, 0)},
+		{"last second of a window", time.Unix(59, 0), "287082", time.Unix(60, 0)},
+		{"start of a window", time.Unix(60, 0), "359152", time.Unix(90, 0)},
+		{"sub-second time", time.Unix(95, int64(500*time.Millisecond)), "969429", time.Unix(120, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, expiresAt := codes.Generate(rfc4226Secret, tt.now)
+			if code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if !expiresAt.Equal(tt.wantExpiresAt) {
+				t.Errorf("expiresAt = %v, want %v", expiresAt, tt.wantExpiresAt)
+			}
+		})
+	}
+}
+
+func TestCheckinCodesVerify(t *testing.T) {
+	// Counter 3 is the current window.
+	now := time.Unix(95, 0)
+
+	tests := []struct {
+		name string
+		skew int
+		code string
+		want bool
+	}{
+		{"current window", 1, "969429", true},
+		{"previous window within skew", 1, "359152", true},
+		{"window before the skew", 1, "287082", false},
+		{"next window", 1, "338314", false},
+		{"previous window without skew", 0, "359152", false},
+		{"current window without skew", 0, "969429", true},
+		{"wider skew", 2, "287082", true},
+		{"wrong code", 1, "000000", false},
+		{"truncated code", 1, "96942", false},
+		{"empty code", 1, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := CheckinCodes{Period: 30 * time.Second, Skew: tt.skew}
+			if got := codes.Verify(rfc4226Secret, tt.code, now); got != tt.want {
+				t.Errorf("Verify(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckinCodesRoundTrip(t *testing.T) {
+	codes := CheckinCodes{Period: 15 * time.Second, Skew: 1}
+	secret, err := generateSecret(sessionSecretSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued := time.Unix(1_700_000_000, 0)
+	code, expiresAt := codes.Generate(secret, issued)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"when issued", issued, true},
+		{"just before rotation", expiresAt.Add(-time.Nanosecond), true},
+		{"one window later", expiresAt, true},
+		{"two windows later", expiresAt.Add(codes.Period), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes.Verify(secret, code, tt.at); got != tt.want {
+				t.Errorf("Verify at %v = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
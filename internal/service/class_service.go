@@ -10,24 +10,74 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/mail"
 	"github.com/tahiriqbal095/attendify/internal/models"
 	"github.com/tahiriqbal095/attendify/internal/repository"
 )
 
 type ClassService interface {
 	CreateClass(ctx context.Context, teacherID uuid.UUID, input *models.CreateClassInput) (*models.Class, error)
-	GetClass(ctx context.Context, id uuid.UUID) (*models.Class, error)
+	// GetClass returns a class to its staff and enrolled students.
+	GetClass(ctx context.Context, userID, classID uuid.UUID) (*models.Class, error)
 	GetClassByCode(ctx context.Context, code string) (*models.Class, error)
-	GetTeacherClasses(ctx context.Context, teacherID uuid.UUID) ([]models.Class, error)
+	// GetTeacherClasses returns the classes the user is on the staff of, in
+	// any role: the active ones, or the archived ones when archived is set.
+	GetTeacherClasses(ctx context.Context, teacherID uuid.UUID, archived bool) ([]models.Class, error)
+	UpdateClass(ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateClassInput) (*models.Class, error)
+	// ArchiveClass hides a class and closes it to new enrollments and
+	// sessions, keeping its history. RestoreClass undoes it.
+	ArchiveClass(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error)
+	RestoreClass(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error)
+	// DeleteClass removes a class with all its enrollments and attendance (admins only).
 	DeleteClass(ctx context.Context, teacherID, classID uuid.UUID) error
+	UpdateGeofence(ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateGeofenceInput) (*models.Class, error)
+	// RegenerateCode replaces the join code, so the old one stops working,
+	// and restarts the use count.
+	RegenerateCode(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error)
+	UpdateCodePolicy(
+		ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateCodePolicyInput,
+	) (*models.Class, error)
+	ListStaff(ctx context.Context, userID, classID uuid.UUID) ([]models.StaffMember, error)
+	AddStaff(ctx context.Context, userID, classID uuid.UUID, input *models.AddStaffInput) (*models.StaffMember, error)
+	// RemoveStaff takes a user off the class staff. Staff may always remove
+	// themselves; the owner can never be removed.
+	RemoveStaff(ctx context.Context, userID, classID, staffUserID uuid.UUID) error
 }
 
 type classService struct {
-	classRepo repository.ClassRepository
+	classRepo      repository.ClassRepository
+	staffRepo      repository.ClassStaffRepository
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	enrollmentRepo repository.EnrollmentRepository
+	authorizer     Authorizer
+	transactor     repository.Transactor
+	audit          AuditService
+	mailer         mail.Mailer
 }
 
-func NewClassService(classRepo repository.ClassRepository) ClassService {
-	return &classService{classRepo: classRepo}
+func NewClassService(
+	classRepo repository.ClassRepository,
+	staffRepo repository.ClassStaffRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	mailer mail.Mailer,
+) ClassService {
+	return &classService{
+		classRepo:      classRepo,
+		staffRepo:      staffRepo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		enrollmentRepo: enrollmentRepo,
+		authorizer:     authorizer,
+		transactor:     transactor,
+		audit:          audit,
+		mailer:         mailer,
+	}
 }
 
 func (s *classService) CreateClass(ctx context.Context, teacherID uuid.UUID, input *models.CreateClassInput) (*models.Class, error) {
@@ -44,23 +94,35 @@ func (s *classService) CreateClass(ctx context.Context, teacherID uuid.UUID, inp
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.classRepo.Create(ctx, class); err != nil {
-		return nil, fmt.Errorf("failed to create class: %w", err)
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.Create(ctx, class); err != nil {
+			return fmt.Errorf("failed to create class: %w", err)
+		}
+		owner := &models.ClassStaff{
+			ClassID:   class.ID,
+			UserID:    teacherID,
+			Role:      models.ClassRoleOwner,
+			CreatedAt: class.CreatedAt,
+		}
+		if err := s.staffRepo.Create(ctx, owner); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassCreated,
+			EntityType: models.EntityClass,
+			EntityID:   class.ID,
+			After:      class.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return class, nil
 }
 
-func (s *classService) GetClass(ctx context.Context, id uuid.UUID) (*models.Class, error) {
-	class, err := s.classRepo.GetByID(ctx, id)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrClassNotFound
-		}
-		return nil, fmt.Errorf("failed to get class: %w", err)
-	}
-
-	return class, nil
+func (s *classService) GetClass(ctx context.Context, userID, classID uuid.UUID) (*models.Class, error) {
+	return s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermClassView)
 }
 
 func (s *classService) GetClassByCode(ctx context.Context, code string) (*models.Class, error) {
@@ -75,8 +137,10 @@ func (s *classService) GetClassByCode(ctx context.Context, code string) (*models
 	return class, nil
 }
 
-func (s *classService) GetTeacherClasses(ctx context.Context, teacherID uuid.UUID) ([]models.Class, error) {
-	classes, err := s.classRepo.GetByTeacherID(ctx, teacherID)
+func (s *classService) GetTeacherClasses(
+	ctx context.Context, teacherID uuid.UUID, archived bool,
+) ([]models.Class, error) {
+	classes, err := s.classRepo.GetByStaffUserID(ctx, teacherID, archived)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get teacher classes: %w", err)
 	}
@@ -84,24 +148,392 @@ func (s *classService) GetTeacherClasses(ctx context.Context, teacherID uuid.UUI
 	return classes, nil
 }
 
+// UpdateClass changes a class's metadata (requires class:update).
+func (s *classService) UpdateClass(
+	ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateClassInput,
+) (*models.Class, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermClassUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name == nil || *input.Name == class.Name {
+		return class, nil
+	}
+
+	before := class.ToResponse()
+	class.Name = *input.Name
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.UpdateName(ctx, classID, class.Name); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassUpdated,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     before,
+			After:      class.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// ArchiveClass requires class:archive. A class with an open session must
+// have it closed first, so no attendance is left half taken.
+func (s *classService) ArchiveClass(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermClassArchive); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return s.setArchived(ctx, classID, &now, models.AuditClassArchived)
+}
+
+// RestoreClass requires class:archive.
+func (s *classService) RestoreClass(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermClassArchive); err != nil {
+		return nil, err
+	}
+
+	return s.setArchived(ctx, classID, nil, models.AuditClassRestored)
+}
+
+// setArchived archives the class at archivedAt, or restores it when nil.
+// It holds the class lock that OpenSession takes, so a session cannot open
+// while the class is being archived.
+func (s *classService) setArchived(
+	ctx context.Context, classID uuid.UUID, archivedAt *time.Time, action string,
+) (*models.Class, error) {
+	var class *models.Class
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		class, err = s.classRepo.GetByIDForUpdate(ctx, classID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		if class.IsArchived() == (archivedAt != nil) {
+			return nil
+		}
+
+		if archivedAt != nil {
+			_, err := s.sessionRepo.GetOpenByClassID(ctx, classID, *archivedAt)
+			if err == nil {
+				return ErrClassHasOpenSession
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("failed to get open session: %w", err)
+			}
+		}
+
+		before := class.ToResponse()
+		class.ArchivedAt = archivedAt
+		if err := s.classRepo.SetArchived(ctx, class.ID, archivedAt); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     action,
+			EntityType: models.EntityClass,
+			EntityID:   class.ID,
+			Before:     before,
+			After:      class.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
 func (s *classService) DeleteClass(ctx context.Context, teacherID, classID uuid.UUID) error {
-	class, err := s.classRepo.GetByID(ctx, classID)
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermClassDelete)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.Delete(ctx, classID); err != nil {
+			return fmt.Errorf("failed to delete class: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassDeleted,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     class.ToResponse(),
+		})
+	})
+}
+
+// UpdateGeofence sets or clears the area students must be inside to mark attendance.
+// Disabling keeps the stored location so it can be re-enabled later.
+func (s *classService) UpdateGeofence(
+	ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateGeofenceInput,
+) (*models.Class, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermClassUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	geofence := class.Geofence
+	if input.Enabled != nil {
+		geofence.Enabled = *input.Enabled
+	}
+	if input.Latitude != nil {
+		geofence.Latitude = input.Latitude
+	}
+	if input.Longitude != nil {
+		geofence.Longitude = input.Longitude
+	}
+	if input.RadiusMeters != nil {
+		geofence.RadiusMeters = input.RadiusMeters
+	}
+
+	if geofence.Enabled && (geofence.Latitude == nil || geofence.Longitude == nil || geofence.RadiusMeters == nil) {
+		return nil, ErrGeofenceIncomplete
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.UpdateGeofence(ctx, classID, &geofence); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return fmt.Errorf("failed to update geofence: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditGeofenceUpdated,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     class.Geofence,
+			After:      geofence,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	class.Geofence = geofence
+
+	return class, nil
+}
+
+// RegenerateCode requires roster:manage.
+func (s *classService) RegenerateCode(ctx context.Context, teacherID, classID uuid.UUID) (*models.Class, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermRosterManage)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.generateUniqueCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	before := class.ToResponse()
+	class.Code = code
+	class.CodePolicy.Uses = 0
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.UpdateCode(ctx, classID, code); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				return ErrClassNotFound
+			case errors.Is(err, repository.ErrDuplicateKey):
+				// Another class took the code since generateUniqueCode checked it.
+				return ErrCodeGeneration
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassCodeRegenerated,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     before,
+			After:      class.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// UpdateCodePolicy enables or disables joining by code and sets its limits (requires roster:manage).
+func (s *classService) UpdateCodePolicy(
+	ctx context.Context, teacherID, classID uuid.UUID, input *models.UpdateCodePolicyInput,
+) (*models.Class, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermRosterManage)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := models.CodePolicy{
+		Enabled:   input.Enabled,
+		ExpiresAt: input.ExpiresAt,
+		MaxUses:   input.MaxUses,
+		Uses:      class.CodePolicy.Uses,
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.classRepo.UpdateCodePolicy(ctx, classID, &policy); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassCodePolicyUpdated,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     class.CodePolicy,
+			After:      policy,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	class.CodePolicy = policy
+
+	return class, nil
+}
+
+// ListStaff returns a class's owner, co-teachers and assistants (requires roster:view).
+func (s *classService) ListStaff(ctx context.Context, userID, classID uuid.UUID) ([]models.StaffMember, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterView); err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.ListByClassID(ctx, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list class staff: %w", err)
+	}
+
+	return staff, nil
+}
+
+// AddStaff gives an existing account a staff role in the class and emails
+// them about it (requires staff:manage).
+func (s *classService) AddStaff(
+	ctx context.Context, userID, classID uuid.UUID, input *models.AddStaffInput,
+) (*models.StaffMember, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermStaffManage)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return ErrClassNotFound
+			return nil, ErrUserNotFound
 		}
-		return fmt.Errorf("failed to get class: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsDisabled() {
+		return nil, ErrUserNotFound
+	}
+	if input.Role == models.ClassRoleCoTeacher && user.Role != models.RoleTeacher {
+		return nil, ErrCoTeacherNotTeacher
+	}
+
+	inviter, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	staff := &models.ClassStaff{
+		ClassID:   classID,
+		UserID:    user.ID,
+		Role:      input.Role,
+		CreatedAt: time.Now(),
 	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Lock the class as enrollments do, so the user cannot join as a
+		// student while being added.
+		if _, err := s.classRepo.GetByIDForUpdate(ctx, classID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+
+		// Students of the class cannot also be its staff, whether their
+		// enrollment is active, pending or waitlisted.
+		_, err := s.enrollmentRepo.Get(ctx, classID, user.ID)
+		switch {
+		case err == nil:
+			return ErrAlreadyClassMember
+		case !errors.Is(err, repository.ErrNotFound):
+			return err
+		}
 
-	if class.TeacherID != teacherID {
-		return ErrNotClassOwner
+		if err := s.staffRepo.Create(ctx, staff); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrAlreadyClassMember
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditStaffAdded,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			After:      staff,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.classRepo.Delete(ctx, classID); err != nil {
-		return fmt.Errorf("failed to delete class: %w", err)
+	// The assignment stands even if the notification cannot be queued.
+	_ = s.mailer.Send(ctx, staffAddedMessage(user, inviter, class, input.Role))
+
+	return &models.StaffMember{User: user.ToResponse(), Role: staff.Role, AddedAt: staff.CreatedAt}, nil
+}
+
+func (s *classService) RemoveStaff(ctx context.Context, userID, classID, staffUserID uuid.UUID) error {
+	if userID != staffUserID {
+		if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermStaffManage); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		role, err := s.staffRepo.GetRole(ctx, classID, staffUserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrStaffNotFound
+			}
+			return err
+		}
+		if role == models.ClassRoleOwner {
+			return ErrCannotRemoveOwner
+		}
+
+		staff, err := s.staffRepo.Delete(ctx, classID, staffUserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrStaffNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditStaffRemoved,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     staff,
+		})
+	})
 }
 
 func (s *classService) generateUniqueCode(ctx context.Context) (string, error) {
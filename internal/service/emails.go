@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tahiriqbal095/attendify/internal/mail"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+func passwordResetMessage(user *models.User, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Attendify password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset your Attendify password. Open the link below
+to choose a new one. It works once and expires in %s.
+
+%s
+
+If you did not ask for this, you can ignore this email; your password is unchanged.
+`, user.Name, formatTTL(ttl), link),
+	}
+}
+
+func emailVerificationMessage(user *models.User, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your Attendify email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Welcome to Attendify! Open the link below to confirm this is your email
+address. It expires in %s.
+
+%s
+
+If you did not create an account, you can ignore this email.
+`, user.Name, formatTTL(ttl), link),
+	}
+}
+
+func accountLockedMessage(user *models.User, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Your Attendify account has been locked",
+		Body: fmt.Sprintf(`Hi %s,
+
+We locked your Attendify account after several failed sign-in attempts.
+It unlocks by itself in %s, or right away if you open the link below.
+
+%s
+
+If these attempts were not you, someone may know your email address;
+consider resetting your password after unlocking.
+`, user.Name, formatTTL(ttl), link),
+	}
+}
+
+func staffAddedMessage(user, inviter *models.User, class *models.Class, role models.ClassRole) mail.Message {
+	title := "an assistant"
+	if role == models.ClassRoleCoTeacher {
+		title = "a co-teacher"
+	}
+
+	return mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("You have been added to %s", class.Name),
+		Body: fmt.Sprintf(`Hi %s,
+
+%s added you as %s of %s on Attendify. The class now
+appears in your class list.
+
+If you were not expecting this, you can remove yourself from the class staff.
+`, user.Name, inviter.Name, title, class.Name),
+	}
+}
+
+func rosterInviteMessage(user, inviter *models.User, class *models.Class, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("You have been enrolled in %s", class.Name),
+		Body: fmt.Sprintf(`Hi %s,
+
+%s enrolled you in %s on Attendify and created an account for
+you. Open the link below to choose a password. It works once and expires in %s.
+
+%s
+
+If the link has expired, use "Forgot password" on the sign-in page with this
+email address.
+`, user.Name, inviter.Name, class.Name, formatTTL(ttl), link),
+	}
+}
+
+func classInvitationMessage(email string, inviter *models.User, class *models.Class, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Name, class.Name),
+		Body: fmt.Sprintf(`Hi,
+
+%s invited you to join %s on Attendify. Open the link below to
+accept; you can create an account there if you do not have one yet. The
+link works once and expires in %s.
+
+%s
+
+If you were not expecting this, you can ignore this email.
+`, inviter.Name, class.Name, formatTTL(ttl), link),
+	}
+}
+
+// formatTTL renders a link lifetime for humans, e.g. "1 hour" or "30 minutes".
+func formatTTL(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return plural(int(d/time.Minute), "minute")
+	default:
+		return d.String()
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
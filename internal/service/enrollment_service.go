@@ -12,25 +12,58 @@ import (
 )
 
 type EnrollmentService interface {
+	// EnrollByCode enrolls a student, or files a pending request if the class
+	// requires approval, or waitlists them if the class is full.
 	EnrollByCode(ctx context.Context, classCode string, studentID uuid.UUID) (*models.Enrollment, error)
 	GetStudentClasses(ctx context.Context, studentID uuid.UUID) ([]models.EnrollmentWithClass, error)
-	GetClassStudents(ctx context.Context, classID uuid.UUID) ([]models.StudentInClass, error)
+	GetStudentRequests(ctx context.Context, studentID uuid.UUID) ([]models.MyEnrollmentRequest, error)
+	GetClassStudents(ctx context.Context, userID, classID uuid.UUID) ([]models.StudentInClass, error)
+	ListRequests(
+		ctx context.Context, userID, classID uuid.UUID, status models.EnrollmentStatus,
+	) ([]models.EnrollmentRequest, error)
+	// Approve admits a pending student, onto the waitlist if the class is full.
+	// It does not count against the class code's max_uses: the teacher, not
+	// the code, decides who gets in.
+	Approve(ctx context.Context, userID, classID, studentID uuid.UUID) (*models.Enrollment, error)
+	// Reject drops a pending or waitlisted enrollment.
+	Reject(ctx context.Context, userID, classID, studentID uuid.UUID) error
+	// UpdatePolicy sets approval and capacity; raising capacity admits waitlisted students.
+	UpdatePolicy(
+		ctx context.Context, userID, classID uuid.UUID, input *models.UpdateEnrollmentPolicyInput,
+	) (*models.Class, error)
+	// Unenroll removes a student in any status. A freed seat goes to the
+	// longest-waiting waitlisted student.
 	Unenroll(ctx context.Context, classID, studentID uuid.UUID) error
 	IsEnrolled(ctx context.Context, classID, studentID uuid.UUID) (bool, error)
 }
 
 type enrollmentService struct {
-	enrollmentRepo repository.EnrollmentRepository
-	classRepo      repository.ClassRepository
+	enrollmentRepo     repository.EnrollmentRepository
+	classRepo          repository.ClassRepository
+	userRepo           repository.UserRepository
+	authorizer         Authorizer
+	transactor         repository.Transactor
+	audit              AuditService
+	verificationPolicy VerificationPolicy
 }
 
 func NewEnrollmentService(
 	enrollmentRepo repository.EnrollmentRepository,
 	classRepo repository.ClassRepository,
+	userRepo repository.UserRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	verificationPolicy VerificationPolicy,
 ) EnrollmentService {
 	return &enrollmentService{
-		enrollmentRepo: enrollmentRepo,
-		classRepo:      classRepo,
+		enrollmentRepo:     enrollmentRepo,
+		classRepo:          classRepo,
+		userRepo:           userRepo,
+		authorizer:         authorizer,
+		transactor:         transactor,
+		audit:              audit,
+		verificationPolicy: verificationPolicy,
 	}
 }
 
@@ -38,6 +71,16 @@ func NewEnrollmentService(
 func (s *enrollmentService) EnrollByCode(
 	ctx context.Context, classCode string, studentID uuid.UUID,
 ) (*models.Enrollment, error) {
+	if s.verificationPolicy.BlocksEnrollment() {
+		student, err := s.userRepo.GetByID(ctx, studentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get student: %w", err)
+		}
+		if !student.IsEmailVerified() {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
 	class, err := s.classRepo.GetByCode(ctx, classCode)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
@@ -45,17 +88,22 @@ func (s *enrollmentService) EnrollByCode(
 		}
 		return nil, fmt.Errorf("failed to find class: %w", err)
 	}
+	if err := checkJoinCode(class, classCode, time.Now()); err != nil {
+		return nil, err
+	}
 
-	// Check if already enrolled
-	enrolled, err := s.enrollmentRepo.IsEnrolled(ctx, class.ID, studentID)
+	// Check if already enrolled, or on the class staff
+	role, err := s.authorizer.ClassRole(ctx, studentID, class.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check enrollment: %w", err)
+		return nil, err
 	}
-	if enrolled {
+	switch {
+	case role == models.ClassRoleStudent:
 		return nil, ErrAlreadyEnrolled
+	case role.IsStaff():
+		return nil, ErrAlreadyClassMember
 	}
 
-	// Create enrollment
 	enrollment := &models.Enrollment{
 		ID:         uuid.New(),
 		ClassID:    class.ID,
@@ -63,11 +111,42 @@ func (s *enrollmentService) EnrollByCode(
 		EnrolledAt: time.Now(),
 	}
 
-	if err := s.enrollmentRepo.Create(ctx, enrollment); err != nil {
-		if errors.Is(err, repository.ErrDuplicateKey) {
-			return nil, ErrAlreadyEnrolled
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		class, err := s.lockClass(ctx, class.ID)
+		if err != nil {
+			return err
+		}
+		// The class may have been archived, or its code changed, since it was looked up.
+		if err := checkJoinCode(class, classCode, time.Now()); err != nil {
+			return err
+		}
+		if enrollment.Status, err = s.admissionStatus(ctx, class); err != nil {
+			return err
+		}
+
+		if err := s.enrollmentRepo.Create(ctx, enrollment); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				// Active enrollments were ruled out above.
+				return ErrEnrollmentAlreadyRequested
+			}
+			return fmt.Errorf("failed to create enrollment: %w", err)
 		}
-		return nil, fmt.Errorf("failed to create enrollment: %w", err)
+		// Counted in the same transaction so a failed join does not use up
+		// the code. Pending requests never count; a teacher admits each one.
+		if enrollment.Status != models.EnrollmentPending {
+			if err := s.useCode(ctx, class.ID); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentCreated,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			After:      enrollment,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return enrollment, nil
@@ -85,17 +164,25 @@ func (s *enrollmentService) GetStudentClasses(
 	return classes, nil
 }
 
-// GetClassStudents returns all students enrolled in a class with user details.
+// GetStudentRequests returns the student's pending and waitlisted enrollments.
+func (s *enrollmentService) GetStudentRequests(
+	ctx context.Context, studentID uuid.UUID,
+) ([]models.MyEnrollmentRequest, error) {
+	requests, err := s.enrollmentRepo.ListRequestsByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollment requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// GetClassStudents returns all students enrolled in a class with user details
+// (requires roster:view).
 func (s *enrollmentService) GetClassStudents(
-	ctx context.Context, classID uuid.UUID,
+	ctx context.Context, userID, classID uuid.UUID,
 ) ([]models.StudentInClass, error) {
-	// Verify class exists
-	_, err := s.classRepo.GetByID(ctx, classID)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrClassNotFound
-		}
-		return nil, fmt.Errorf("failed to get class: %w", err)
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterView); err != nil {
+		return nil, err
 	}
 
 	students, err := s.enrollmentRepo.GetStudentsWithDetailsByClassID(ctx, classID)
@@ -106,20 +193,288 @@ func (s *enrollmentService) GetClassStudents(
 	return students, nil
 }
 
-// Unenroll removes a student from a class.
-func (s *enrollmentService) Unenroll(ctx context.Context, classID, studentID uuid.UUID) error {
-	err := s.enrollmentRepo.Delete(ctx, classID, studentID)
+// ListRequests returns a class's pending and waitlisted enrollments (requires roster:view).
+func (s *enrollmentService) ListRequests(
+	ctx context.Context, userID, classID uuid.UUID, status models.EnrollmentStatus,
+) ([]models.EnrollmentRequest, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterView); err != nil {
+		return nil, err
+	}
+
+	requests, err := s.enrollmentRepo.ListRequestsByClassID(ctx, classID, status)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotEnrolled
+		return nil, fmt.Errorf("failed to list enrollment requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// Approve requires roster:manage.
+func (s *enrollmentService) Approve(
+	ctx context.Context, userID, classID, studentID uuid.UUID,
+) (*models.Enrollment, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage); err != nil {
+		return nil, err
+	}
+
+	var enrollment *models.Enrollment
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		class, err := s.lockClass(ctx, classID)
+		if err != nil {
+			return err
+		}
+		if class.IsArchived() {
+			return ErrClassArchived
+		}
+
+		active, err := s.enrollmentRepo.CountActive(ctx, classID)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to unenroll student: %w", err)
+		status := models.EnrollmentWaitlisted
+		if class.Enrollment.HasRoom(active) {
+			status = models.EnrollmentActive
+		}
+
+		enrollment, err = s.enrollmentRepo.UpdateStatus(
+			ctx, classID, studentID, models.EnrollmentPending, status, time.Now(),
+		)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrEnrollmentRequestNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentApproved,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			Before:     map[string]models.EnrollmentStatus{"status": models.EnrollmentPending},
+			After:      enrollment,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return enrollment, nil
+}
+
+// Reject requires roster:manage.
+func (s *enrollmentService) Reject(ctx context.Context, userID, classID, studentID uuid.UUID) error {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage); err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		enrollment, err := s.enrollmentRepo.Get(ctx, classID, studentID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrEnrollmentRequestNotFound
+			}
+			return err
+		}
+		if enrollment.Status == models.EnrollmentActive {
+			return ErrEnrollmentRequestNotFound
+		}
+
+		if _, err := s.enrollmentRepo.Delete(ctx, classID, studentID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrEnrollmentRequestNotFound
+			}
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentRejected,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			Before:     enrollment,
+		})
+	})
+}
+
+// UpdatePolicy requires roster:manage. Pending requests stay pending when
+// approval is turned off; teachers still decide on them.
+func (s *enrollmentService) UpdatePolicy(
+	ctx context.Context, userID, classID uuid.UUID, input *models.UpdateEnrollmentPolicyInput,
+) (*models.Class, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage); err != nil {
+		return nil, err
+	}
+
+	var class *models.Class
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if class, err = s.lockClass(ctx, classID); err != nil {
+			return err
+		}
+
+		before := class.Enrollment
+		class.Enrollment = models.EnrollmentPolicy{
+			RequiresApproval: input.RequiresApproval,
+			Capacity:         input.Capacity,
+		}
+		if err := s.classRepo.UpdateEnrollmentPolicy(ctx, classID, &class.Enrollment); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassEnrollmentPolicyUpdated,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			Before:     before,
+			After:      class.Enrollment,
+		}); err != nil {
+			return err
+		}
+
+		return s.promoteWaitlist(ctx, class)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+func (s *enrollmentService) Unenroll(ctx context.Context, classID, studentID uuid.UUID) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Lock the class before the enrollment, in the same order as EnrollByCode.
+		class, err := s.lockClass(ctx, classID)
+		if err != nil {
+			if errors.Is(err, ErrClassNotFound) {
+				return ErrNotEnrolled
+			}
+			return err
+		}
+
+		enrollment, err := s.enrollmentRepo.Delete(ctx, classID, studentID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotEnrolled
+			}
+			return fmt.Errorf("failed to unenroll student: %w", err)
+		}
+		if err := s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentDeleted,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			Before:     enrollment,
+		}); err != nil {
+			return err
+		}
+
+		if enrollment.Status != models.EnrollmentActive {
+			return nil
+		}
+		return s.promoteWaitlist(ctx, class)
+	})
 }
 
 // IsEnrolled checks if a student is enrolled in a class.
 func (s *enrollmentService) IsEnrolled(ctx context.Context, classID, studentID uuid.UUID) (bool, error) {
 	return s.enrollmentRepo.IsEnrolled(ctx, classID, studentID)
 }
+
+// lockClass loads and locks a class for the rest of the transaction.
+func (s *enrollmentService) lockClass(ctx context.Context, classID uuid.UUID) (*models.Class, error) {
+	class, err := s.classRepo.GetByIDForUpdate(ctx, classID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// checkJoinCode reports why the class cannot be joined with code, if it cannot.
+func checkJoinCode(class *models.Class, code string, now time.Time) error {
+	switch {
+	case class.Code != code:
+		// Regenerated since the student looked it up.
+		return ErrClassNotFound
+	case class.IsArchived():
+		return ErrClassArchived
+	case !class.CodePolicy.Enabled:
+		return ErrClassCodeDisabled
+	case class.CodePolicy.IsExpired(now):
+		return ErrClassCodeExpired
+	case class.CodePolicy.IsExhausted():
+		return ErrClassCodeExhausted
+	}
+	return nil
+}
+
+// useCode counts a student admitted by the class code.
+func (s *enrollmentService) useCode(ctx context.Context, classID uuid.UUID) error {
+	if err := s.classRepo.UseCode(ctx, classID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrClassCodeExhausted
+		}
+		return err
+	}
+	return nil
+}
+
+// admissionStatus decides where a new enrollment starts. The class must be locked.
+func (s *enrollmentService) admissionStatus(ctx context.Context, class *models.Class) (models.EnrollmentStatus, error) {
+	if class.Enrollment.RequiresApproval {
+		return models.EnrollmentPending, nil
+	}
+
+	active, err := s.enrollmentRepo.CountActive(ctx, class.ID)
+	if err != nil {
+		return "", err
+	}
+	if class.Enrollment.HasRoom(active) {
+		return models.EnrollmentActive, nil
+	}
+
+	return models.EnrollmentWaitlisted, nil
+}
+
+// promoteWaitlist fills free seats from the waitlist, longest-waiting first.
+// Archived classes admit nobody, so their waitlist is left alone. The class
+// must be locked.
+func (s *enrollmentService) promoteWaitlist(ctx context.Context, class *models.Class) error {
+	if class.IsArchived() {
+		return nil
+	}
+
+	active, err := s.enrollmentRepo.CountActive(ctx, class.ID)
+	if err != nil {
+		return err
+	}
+
+	for ; class.Enrollment.HasRoom(active); active++ {
+		next, err := s.enrollmentRepo.NextWaitlisted(ctx, class.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		promoted, err := s.enrollmentRepo.UpdateStatus(
+			ctx, class.ID, next.StudentID, models.EnrollmentWaitlisted, models.EnrollmentActive, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentPromoted,
+			EntityType: models.EntityEnrollment,
+			EntityID:   promoted.ID,
+			Before:     next,
+			After:      promoted,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+func TestCheckJoinCode(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	maxUses := 2
+
+	tests := []struct {
+		name    string
+		class   models.Class
+		code    string
+		wantErr error
+	}{
+		{"open", models.Class{Code: "ABC123", CodePolicy: models.CodePolicy{Enabled: true}}, "ABC123", nil},
+		{"regenerated", models.Class{Code: "XYZ789", CodePolicy: models.CodePolicy{Enabled: true}}, "ABC123", ErrClassNotFound},
+		{"archived", models.Class{Code: "ABC123", ArchivedAt: &past, CodePolicy: models.CodePolicy{Enabled: true}}, "ABC123", ErrClassArchived},
+		{"disabled", models.Class{Code: "ABC123"}, "ABC123", ErrClassCodeDisabled},
+		{"expired", models.Class{Code: "ABC123", CodePolicy: models.CodePolicy{Enabled: true, ExpiresAt: &past}}, "ABC123", ErrClassCodeExpired},
+		{"not yet expired", models.Class{Code: "ABC123", CodePolicy: models.CodePolicy{Enabled: true, ExpiresAt: &future}}, "ABC123", nil},
+		{"exhausted", models.Class{Code: "ABC123", CodePolicy: models.CodePolicy{Enabled: true, MaxUses: &maxUses, Uses: 2}}, "ABC123", ErrClassCodeExhausted},
+		{"uses left", models.Class{Code: "ABC123", CodePolicy: models.CodePolicy{Enabled: true, MaxUses: &maxUses, Uses: 1}}, "ABC123", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkJoinCode(&tt.class, tt.code, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkJoinCode() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
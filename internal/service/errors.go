@@ -9,10 +9,73 @@ var (
 	ErrEmailTaken         = errors.New("email already registered")
 	ErrInvalidToken       = errors.New("invalid or expired token")
 
-	ErrClassNotFound  = errors.New("class not found")
-	ErrNotClassOwner  = errors.New("not the owner of this class")
-	ErrCodeGeneration = errors.New("failed to generate unique class code")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused; session revoked")
+	ErrInvalidResetToken   = errors.New("invalid or expired password reset token")
+
+	ErrAccountDisabled       = errors.New("account disabled")
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrAccountLocked         = errors.New("account temporarily locked")
+	ErrLoginThrottled        = errors.New("too many failed sign-in attempts")
+	ErrInvalidUnlockToken    = errors.New("invalid or expired unlock token")
+
+	ErrEmailNotVerified         = errors.New("email address not verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrVerificationThrottled    = errors.New("verification email requested too recently")
+
+	ErrMFARequired         = errors.New("two-factor authentication is required for this account")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication not enabled")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+	ErrMFASetupNotStarted  = errors.New("two-factor enrollment not started")
+
+	ErrUserNotFound       = errors.New("user not found")
+	ErrCannotModifySelf   = errors.New("admins cannot change their own account this way")
+	ErrUserOwnsClasses    = errors.New("user owns classes")
+	ErrUserTeachesClasses = errors.New("user owns or co-teaches classes")
+
+	ErrClassNotFound         = errors.New("class not found")
+	ErrClassPermissionDenied = errors.New("insufficient permissions for this class")
+	ErrCodeGeneration        = errors.New("failed to generate unique class code")
+	ErrClassArchived         = errors.New("class is archived")
+	ErrClassHasOpenSession   = errors.New("close the open session before archiving the class")
+
+	ErrStaffNotFound       = errors.New("user is not on the staff of this class")
+	ErrAlreadyClassMember  = errors.New("user is already a member of this class")
+	ErrCannotRemoveOwner   = errors.New("the class owner cannot be removed")
+	ErrCoTeacherNotTeacher = errors.New("only teacher accounts can be co-teachers")
+
+	ErrGeofenceIncomplete = errors.New("geofence needs latitude, longitude and radius before it can be enabled")
+
+	ErrClassCodeDisabled  = errors.New("joining this class by code is disabled")
+	ErrClassCodeExpired   = errors.New("class code has expired")
+	ErrClassCodeExhausted = errors.New("class code has no uses left")
 
 	ErrAlreadyEnrolled = errors.New("student already enrolled in this class")
 	ErrNotEnrolled     = errors.New("student not enrolled in this class")
+
+	ErrEnrollmentAlreadyRequested = errors.New("enrollment in this class already requested")
+	ErrEnrollmentRequestNotFound  = errors.New("enrollment request not found")
+
+	ErrRosterInvalid         = errors.New("roster has invalid rows")
+	ErrRosterExceedsCapacity = errors.New("roster would exceed the class capacity")
+	ErrRosterChanged         = errors.New("accounts or enrollments changed during the import")
+
+	ErrInvalidInvitation       = errors.New("invalid or expired invitation")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+
+	ErrAlreadyMarked        = errors.New("attendance already marked for this session")
+	ErrDuplicateRosterEntry = errors.New("student appears more than once in the roster")
+
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionAlreadyOpen = errors.New("a session is already open for this class")
+	ErrSessionClosed      = errors.New("session is closed")
+	ErrNoOpenSession      = errors.New("no open session for this class")
+	ErrInvalidCheckinCode = errors.New("invalid or expired check-in code")
+	ErrCheckinLocked      = errors.New("too many wrong check-in codes for this session")
+
+	ErrLocationRequired = errors.New("location is required to mark attendance in this class")
+	ErrOutsideGeofence  = errors.New("location is outside the class area")
 )
@@ -0,0 +1,19 @@
+package service
+
+import "math"
+
+// earthRadiusMeters is the mean Earth radius used by the haversine formula.
+const earthRadiusMeters = 6371000
+
+// haversineMeters returns the great-circle distance between two coordinates in meters.
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
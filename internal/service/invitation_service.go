@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/mail"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// InvitationService invites specific people to a class by email, alongside
+// the shared class code. Accepting an invitation bypasses enrollment
+// approval and the code policy, but a full class still waitlists.
+type InvitationService interface {
+	// Invite emails an invitation to each address. An outstanding invitation
+	// to the same address is revoked, so only the newest link works.
+	Invite(ctx context.Context, userID, classID uuid.UUID, emails []string) ([]models.Invitation, error)
+	ListOutstanding(ctx context.Context, userID, classID uuid.UUID) ([]models.Invitation, error)
+	Revoke(ctx context.Context, userID, classID, invitationID uuid.UUID) error
+	// Accept enrolls the signed-in student. The invitation must have been
+	// sent to their email address, which it thereby verifies.
+	Accept(ctx context.Context, studentID uuid.UUID, token string) (*models.Enrollment, error)
+	// AcceptWithSignup creates a student account for the invited address
+	// and enrolls it.
+	AcceptWithSignup(
+		ctx context.Context, input *models.AcceptInvitationWithSignupInput,
+	) (*models.InvitationSignupResponse, error)
+}
+
+type InvitationConfig struct {
+	// TTL is how long an emailed invitation works.
+	TTL time.Duration
+	// AcceptURL is the frontend page that receives the invitation token.
+	AcceptURL string
+}
+
+type invitationService struct {
+	invitationRepo repository.InvitationRepository
+	userRepo       repository.UserRepository
+	classRepo      repository.ClassRepository
+	enrollmentRepo repository.EnrollmentRepository
+	authorizer     Authorizer
+	transactor     repository.Transactor
+	audit          AuditService
+	mailer         mail.Mailer
+	config         InvitationConfig
+}
+
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	userRepo repository.UserRepository,
+	classRepo repository.ClassRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	mailer mail.Mailer,
+	config InvitationConfig,
+) InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		classRepo:      classRepo,
+		enrollmentRepo: enrollmentRepo,
+		authorizer:     authorizer,
+		transactor:     transactor,
+		audit:          audit,
+		mailer:         mailer,
+		config:         config,
+	}
+}
+
+// Invite requires roster:manage.
+func (s *invitationService) Invite(
+	ctx context.Context, userID, classID uuid.UUID, emails []string,
+) ([]models.Invitation, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage)
+	if err != nil {
+		return nil, err
+	}
+	if class.IsArchived() {
+		return nil, ErrClassArchived
+	}
+
+	inviter, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	var (
+		invitations []models.Invitation
+		tokens      []string
+		seen        = make(map[string]bool, len(emails))
+	)
+	for _, email := range emails {
+		email = models.NormalizeEmail(email)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+
+		token, tokenHash, err := generateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, models.Invitation{
+			ID:        uuid.New(),
+			ClassID:   classID,
+			Email:     email,
+			TokenHash: tokenHash,
+			InvitedBy: &userID,
+			ExpiresAt: now.Add(s.config.TTL),
+			CreatedAt: now,
+		})
+		tokens = append(tokens, token)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for i := range invitations {
+			invitation := &invitations[i]
+			if err := s.invitationRepo.RevokeByEmail(ctx, classID, invitation.Email, now); err != nil {
+				return err
+			}
+			if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+				return err
+			}
+			if err := s.audit.Record(ctx, AuditEntry{
+				Action:     models.AuditInvitationCreated,
+				EntityType: models.EntityInvitation,
+				EntityID:   invitation.ID,
+				After:      invitation,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, invitation := range invitations {
+		link := s.config.AcceptURL + "?" + url.Values{"token": {tokens[i]}}.Encode()
+		// The invitations stand even if an email cannot be queued; the
+		// teacher can send it again.
+		_ = s.mailer.Send(ctx, classInvitationMessage(invitation.Email, inviter, class, link, s.config.TTL))
+	}
+
+	return invitations, nil
+}
+
+// ListOutstanding requires roster:view.
+func (s *invitationService) ListOutstanding(
+	ctx context.Context, userID, classID uuid.UUID,
+) ([]models.Invitation, error) {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterView); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationRepo.ListOutstandingByClassID(ctx, classID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// Revoke requires roster:manage.
+func (s *invitationService) Revoke(ctx context.Context, userID, classID, invitationID uuid.UUID) error {
+	if _, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage); err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		invitation, err := s.invitationRepo.Revoke(ctx, classID, invitationID, time.Now())
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditInvitationRevoked,
+			EntityType: models.EntityInvitation,
+			EntityID:   invitation.ID,
+			After:      invitation,
+		})
+	})
+}
+
+func (s *invitationService) Accept(
+	ctx context.Context, studentID uuid.UUID, token string,
+) (*models.Enrollment, error) {
+	student, err := s.userRepo.GetByID(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student: %w", err)
+	}
+
+	var enrollment *models.Enrollment
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		invitation, err := s.getOutstanding(ctx, token, now)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(invitation.Email, student.Email) {
+			return ErrInvitationEmailMismatch
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, studentID, now); err != nil {
+			return err
+		}
+
+		enrollment, err = s.redeem(ctx, invitation, studentID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func (s *invitationService) AcceptWithSignup(
+	ctx context.Context, input *models.AcceptInvitationWithSignupInput,
+) (*models.InvitationSignupResponse, error) {
+	// Hash before taking row locks; bcrypt is deliberately slow.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var response *models.InvitationSignupResponse
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		invitation, err := s.getOutstanding(ctx, input.Token, now)
+		if err != nil {
+			return err
+		}
+
+		// The invitation went to this address, so it is already verified.
+		user := &models.User{
+			ID:              uuid.New(),
+			Email:           invitation.Email,
+			PasswordHash:    string(hashedPassword),
+			Name:            input.Name,
+			Role:            models.RoleStudent,
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrEmailTaken
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		// The request is unauthenticated, so the new user is its own actor.
+		ctx = requestctx.WithActor(ctx, requestctx.Actor{UserID: user.ID, Role: user.Role})
+		if err := s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditUserRegistered,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+			After:      user.ToResponse(),
+		}); err != nil {
+			return err
+		}
+
+		enrollment, err := s.redeem(ctx, invitation, user.ID, now)
+		if err != nil {
+			return err
+		}
+		response = &models.InvitationSignupResponse{User: user.ToResponse(), Enrollment: enrollment}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// getOutstanding loads and locks the invitation for a token.
+func (s *invitationService) getOutstanding(
+	ctx context.Context, token string, now time.Time,
+) (*models.Invitation, error) {
+	invitation, err := s.invitationRepo.GetByHashForUpdate(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if !invitation.IsOutstanding(now) {
+		return nil, ErrInvalidInvitation
+	}
+
+	return invitation, nil
+}
+
+// redeem enrolls the student and uses up the invitation. An invitation
+// admits a pending request; a waitlisted student keeps their place.
+func (s *invitationService) redeem(
+	ctx context.Context, invitation *models.Invitation, studentID uuid.UUID, now time.Time,
+) (*models.Enrollment, error) {
+	// Lock the class as EnrollByCode does before counting seats.
+	class, err := s.classRepo.GetByIDForUpdate(ctx, invitation.ClassID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if class.IsArchived() {
+		return nil, ErrClassArchived
+	}
+
+	role, err := s.authorizer.ClassRole(ctx, studentID, class.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case role == models.ClassRoleStudent:
+		return nil, ErrAlreadyEnrolled
+	case role.IsStaff():
+		return nil, ErrAlreadyClassMember
+	}
+
+	active, err := s.enrollmentRepo.CountActive(ctx, class.ID)
+	if err != nil {
+		return nil, err
+	}
+	status := models.EnrollmentWaitlisted
+	if class.Enrollment.HasRoom(active) {
+		status = models.EnrollmentActive
+	}
+
+	enrollment, err := s.admit(ctx, class.ID, studentID, status, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.invitationRepo.MarkAccepted(ctx, invitation.ID, studentID, now); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if err := s.audit.Record(ctx, AuditEntry{
+		Action:     models.AuditInvitationAccepted,
+		EntityType: models.EntityInvitation,
+		EntityID:   invitation.ID,
+		Before:     invitation,
+	}); err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// admit creates the enrollment, or moves a pending one to status.
+func (s *invitationService) admit(
+	ctx context.Context, classID, studentID uuid.UUID, status models.EnrollmentStatus, now time.Time,
+) (*models.Enrollment, error) {
+	existing, err := s.enrollmentRepo.Get(ctx, classID, studentID)
+	switch {
+	case err == nil:
+		if existing.Status != models.EnrollmentPending {
+			return existing, nil
+		}
+		enrollment, err := s.enrollmentRepo.UpdateStatus(
+			ctx, classID, studentID, models.EnrollmentPending, status, now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return enrollment, s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentApproved,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			Before:     existing,
+			After:      enrollment,
+		})
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	enrollment := &models.Enrollment{
+		ID:         uuid.New(),
+		ClassID:    classID,
+		StudentID:  studentID,
+		Status:     status,
+		EnrolledAt: now,
+	}
+	if err := s.enrollmentRepo.Create(ctx, enrollment); err != nil {
+		return nil, fmt.Errorf("failed to create enrollment: %w", err)
+	}
+
+	return enrollment, s.audit.Record(ctx, AuditEntry{
+		Action:     models.AuditEnrollmentCreated,
+		EntityType: models.EntityEnrollment,
+		EntityID:   enrollment.ID,
+		After:      enrollment,
+	})
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+)
+
+const (
+	// loginFailureWindow is how long a failed sign-in counts against an account or IP.
+	loginFailureWindow = time.Hour
+	// accountFreeAttempts is how many wrong passwords an account gets before backoff starts.
+	accountFreeAttempts = 3
+	// loginBackoffBase is the first delay, doubled for every further failure.
+	loginBackoffBase = time.Second
+	// loginBackoffMax caps the delay between attempts short of a lockout.
+	loginBackoffMax = 15 * time.Minute
+)
+
+// LoginLimitConfig sets when failed sign-ins slow down and lock out.
+type LoginLimitConfig struct {
+	// LockoutThreshold is the number of failures that locks an account.
+	LockoutThreshold int
+	// LockoutDuration is how long a locked account stays locked unless unlocked by email.
+	LockoutDuration time.Duration
+	// IPThreshold is how many failures a client IP gets before backoff starts.
+	// It is higher than the per-account allowance because of shared networks.
+	IPThreshold int
+}
+
+// LoginLimiter tracks failed sign-ins per account and per client IP.
+// Accounts are keyed by email, so unknown addresses behave like registered ones.
+type LoginLimiter interface {
+	// Check returns ErrAccountLocked or ErrLoginThrottled if the attempt must
+	// be refused without looking at the password.
+	Check(ctx context.Context, email, ip string) error
+	// RecordFailure counts a wrong password. It returns when the account
+	// unlocks if this failure locked it, or nil.
+	RecordFailure(ctx context.Context, email, ip string) (*time.Time, error)
+	// Reset clears an account's failures after a successful sign-in or unlock.
+	Reset(ctx context.Context, email string) error
+	// Cleanup forgets counters that have gone quiet.
+	Cleanup(ctx context.Context) error
+}
+
+type loginLimiter struct {
+	repo   repository.LoginThrottleRepository
+	config LoginLimitConfig
+}
+
+func NewLoginLimiter(repo repository.LoginThrottleRepository, config LoginLimitConfig) LoginLimiter {
+	return &loginLimiter{repo: repo, config: config}
+}
+
+func (l *loginLimiter) Check(ctx context.Context, email, ip string) error {
+	throttles, err := l.repo.GetMany(ctx, []string{accountKey(email), ipKey(ip)})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, t := range throttles {
+		if !t.IsBlocked(now) {
+			continue
+		}
+		if t.Key == ipKey(ip) {
+			return ErrLoginThrottled
+		}
+		return ErrAccountLocked
+	}
+
+	return nil
+}
+
+func (l *loginLimiter) RecordFailure(ctx context.Context, email, ip string) (*time.Time, error) {
+	now := time.Now()
+
+	if ip != "" {
+		t, err := l.repo.RecordFailure(ctx, ipKey(ip), now, loginFailureWindow)
+		if err != nil {
+			return nil, err
+		}
+		if t.Failures > l.config.IPThreshold {
+			if err := l.repo.Block(ctx, t.Key, now.Add(backoff(t.Failures-l.config.IPThreshold))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	t, err := l.repo.RecordFailure(ctx, accountKey(email), now, loginFailureWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.Failures >= l.config.LockoutThreshold:
+		until := now.Add(l.config.LockoutDuration)
+		if err := l.repo.Block(ctx, t.Key, until); err != nil {
+			return nil, err
+		}
+		return &until, nil
+	case t.Failures > accountFreeAttempts:
+		return nil, l.repo.Block(ctx, t.Key, now.Add(backoff(t.Failures-accountFreeAttempts)))
+	}
+
+	return nil, nil
+}
+
+func (l *loginLimiter) Reset(ctx context.Context, email string) error {
+	return l.repo.Delete(ctx, accountKey(email))
+}
+
+func (l *loginLimiter) Cleanup(ctx context.Context) error {
+	return l.repo.DeleteInactiveSince(ctx, time.Now().Add(-loginFailureWindow))
+}
+
+// backoff returns the delay after the nth failure past the free attempts.
+func backoff(n int) time.Duration {
+	delay := loginBackoffBase
+	for i := 1; i < n && delay < loginBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, loginBackoffMax)
+}
+
+func accountKey(email string) string {
+	return "account:" + models.NormalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+)
+
+// revocationCheckTTL bounds how long another instance's revocation can go
+// unnoticed: a "not revoked" answer is cached for at most this long.
+const revocationCheckTTL = 15 * time.Second
+
+// RevocationStore tracks access tokens revoked before their expiry, either one
+// at a time by jti or all of a user's tokens issued before a cutoff.
+type RevocationStore interface {
+	Revoke(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error
+	// RevokeUser rejects every token the user holds now, e.g. after a password
+	// change. Tokens issued afterwards are unaffected.
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+	// Cleanup forgets entries for tokens that have expired.
+	Cleanup(ctx context.Context) error
+}
+
+type revocationEntry struct {
+	revoked bool
+	// until is the token expiry for revoked entries and the recheck time otherwise.
+	until time.Time
+}
+
+type cutoffEntry struct {
+	cutoff  *time.Time
+	checkAt time.Time
+}
+
+// revocationStore is backed by Postgres with an in-memory cache in front, so
+// authenticated requests usually avoid a database round trip.
+type revocationStore struct {
+	revokedTokenRepo repository.RevokedTokenRepository
+	// maxTokenAge is the access token lifetime; older cutoffs cannot match a live token.
+	maxTokenAge time.Duration
+
+	mu      sync.RWMutex
+	tokens  map[uuid.UUID]revocationEntry
+	cutoffs map[uuid.UUID]cutoffEntry
+}
+
+func NewRevocationStore(revokedTokenRepo repository.RevokedTokenRepository, maxTokenAge time.Duration) RevocationStore {
+	return &revocationStore{
+		revokedTokenRepo: revokedTokenRepo,
+		maxTokenAge:      maxTokenAge,
+		tokens:           make(map[uuid.UUID]revocationEntry),
+		cutoffs:          make(map[uuid.UUID]cutoffEntry),
+	}
+}
+
+func (s *revocationStore) Revoke(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.revokedTokenRepo.Create(ctx, jti, userID, expiresAt, time.Now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = revocationEntry{revoked: true, until: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *revocationStore) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	// Stored with the microsecond precision of token iat; a token issued in
+	// the same microsecond counts as revoked.
+	cutoff := time.Now().Truncate(time.Microsecond)
+	if err := s.revokedTokenRepo.RevokeUserBefore(ctx, userID, cutoff); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cutoffs[userID] = cutoffEntry{cutoff: &cutoff, checkAt: time.Now().Add(revocationCheckTTL)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *revocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	revoked, err := s.isTokenRevoked(ctx, claims.TokenID(), claims.ExpiresAt.Time)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	cutoff, err := s.userCutoff(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if cutoff != nil && (claims.IssuedAt == nil || !claims.IssuedAt.After(*cutoff)) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *revocationStore) isTokenRevoked(ctx context.Context, jti uuid.UUID, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.tokens[jti]
+	s.mu.RUnlock()
+	if ok && (entry.revoked || now.Before(entry.until)) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := s.revokedTokenRepo.Exists(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	entry = revocationEntry{until: now.Add(revocationCheckTTL)}
+	if revoked {
+		entry = revocationEntry{revoked: true, until: expiresAt}
+	}
+	s.mu.Lock()
+	s.tokens[jti] = entry
+	s.mu.Unlock()
+
+	return revoked, nil
+}
+
+func (s *revocationStore) userCutoff(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.cutoffs[userID]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.checkAt) {
+		return entry.cutoff, nil
+	}
+
+	cutoff, err := s.revokedTokenRepo.GetUserCutoff(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cutoffs[userID] = cutoffEntry{cutoff: cutoff, checkAt: now.Add(revocationCheckTTL)}
+	s.mu.Unlock()
+
+	return cutoff, nil
+}
+
+func (s *revocationStore) Cleanup(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.revokedTokenRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	if _, err := s.revokedTokenRepo.DeleteUserCutoffsBefore(ctx, now.Add(-s.maxTokenAge)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, entry := range s.tokens {
+		if !now.Before(entry.until) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, entry := range s.cutoffs {
+		if !now.Before(entry.checkAt) {
+			delete(s.cutoffs, userID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/mail"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+)
+
+// RosterService imports class rosters supplied by teachers.
+type RosterService interface {
+	// Import checks every row and, unless dryRun is set or a row has errors,
+	// enrolls them all in one transaction. Rows arrive with any format errors
+	// already recorded. Students without an account get one, with an emailed
+	// link to choose a password. Imports bypass enrollment approval but not
+	// the class capacity.
+	Import(
+		ctx context.Context, userID, classID uuid.UUID, rows []models.RosterRow, dryRun bool,
+	) (*models.RosterImportResult, error)
+}
+
+type RosterConfig struct {
+	// InviteTTL is how long the link emailed to a new account works.
+	InviteTTL time.Duration
+	// SetPasswordURL is the frontend page that receives the invite token.
+	// Invite tokens are password reset tokens with a longer lifetime.
+	SetPasswordURL string
+}
+
+type rosterService struct {
+	userRepo          repository.UserRepository
+	enrollmentRepo    repository.EnrollmentRepository
+	classRepo         repository.ClassRepository
+	passwordResetRepo repository.PasswordResetRepository
+	authorizer        Authorizer
+	transactor        repository.Transactor
+	audit             AuditService
+	mailer            mail.Mailer
+	config            RosterConfig
+}
+
+func NewRosterService(
+	userRepo repository.UserRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	classRepo repository.ClassRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	mailer mail.Mailer,
+	config RosterConfig,
+) RosterService {
+	return &rosterService{
+		userRepo:          userRepo,
+		enrollmentRepo:    enrollmentRepo,
+		classRepo:         classRepo,
+		passwordResetRepo: passwordResetRepo,
+		authorizer:        authorizer,
+		transactor:        transactor,
+		audit:             audit,
+		mailer:            mailer,
+		config:            config,
+	}
+}
+
+// rosterEntry is a planned row with the account it resolved to, if any.
+type rosterEntry struct {
+	row        *models.RosterRow
+	user       *models.User
+	enrollment *models.Enrollment
+}
+
+// invite is a new account's set-password token, emailed after commit.
+type invite struct {
+	user  *models.User
+	token string
+}
+
+func (s *rosterService) Import(
+	ctx context.Context, userID, classID uuid.UUID, rows []models.RosterRow, dryRun bool,
+) (*models.RosterImportResult, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, userID, classID, models.PermRosterManage)
+	if err != nil {
+		return nil, err
+	}
+	if class.IsArchived() {
+		return nil, ErrClassArchived
+	}
+
+	result := &models.RosterImportResult{DryRun: dryRun, Rows: rows}
+	entries, err := s.plan(ctx, classID, result)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid && !dryRun {
+		return result, ErrRosterInvalid
+	}
+	if err := s.checkCapacity(ctx, class, result); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	var invites []invite
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Lock the class as EnrollByCode does, then check the seats again.
+		locked, err := s.classRepo.GetByIDForUpdate(ctx, classID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		if err := s.checkCapacity(ctx, locked, result); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, entry := range entries {
+			token, err := s.apply(ctx, classID, entry, now)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", entry.row.Line, err)
+			}
+			if token != "" {
+				invites = append(invites, invite{user: entry.user, token: token})
+			}
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditClassRosterImported,
+			EntityType: models.EntityClass,
+			EntityID:   classID,
+			After: map[string]int{
+				"created":   result.Created,
+				"enrolled":  result.Enrolled,
+				"activated": result.Activated,
+				"skipped":   result.Skipped,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(invites) > 0 {
+		inviter, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		for _, inv := range invites {
+			link := s.config.SetPasswordURL + "?" + url.Values{"token": {inv.token}}.Encode()
+			// The account stands either way; a student who gets no email can
+			// still set a password through the usual reset flow.
+			_ = s.mailer.Send(ctx, rosterInviteMessage(inv.user, inviter, class, link, s.config.InviteTTL))
+		}
+	}
+
+	return result, nil
+}
+
+// plan works out each row's action and records any errors on the row.
+// Rows that arrive with format errors are left alone.
+func (s *rosterService) plan(
+	ctx context.Context, classID uuid.UUID, result *models.RosterImportResult,
+) ([]rosterEntry, error) {
+	entries := make([]rosterEntry, 0, len(result.Rows))
+	seen := make(map[string]int, len(result.Rows))
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if len(row.Errors) > 0 {
+			continue
+		}
+		// Emails arrive trimmed and lowercased.
+		if line, ok := seen[row.Email]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("duplicate of line %d", line))
+			continue
+		}
+		seen[row.Email] = row.Line
+
+		entry, err := s.planRow(ctx, classID, row)
+		if err != nil {
+			return nil, err
+		}
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		switch row.Action {
+		case models.RosterCreate:
+			result.Created++
+		case models.RosterEnroll:
+			result.Enrolled++
+		case models.RosterActivate:
+			result.Activated++
+		case models.RosterSkip:
+			result.Skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	result.Valid = true
+	for _, row := range result.Rows {
+		if len(row.Errors) > 0 {
+			result.Valid = false
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+func (s *rosterService) planRow(ctx context.Context, classID uuid.UUID, row *models.RosterRow) (rosterEntry, error) {
+	entry := rosterEntry{row: row}
+
+	user, err := s.userRepo.GetByEmail(ctx, row.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			row.Action = models.RosterCreate
+			return entry, nil
+		}
+		return entry, fmt.Errorf("failed to get user: %w", err)
+	}
+	entry.user = user
+
+	switch {
+	case user.IsDisabled():
+		row.Errors = append(row.Errors, "account is disabled")
+		return entry, nil
+	case user.Role != models.RoleStudent:
+		row.Errors = append(row.Errors, "account is not a student account")
+		return entry, nil
+	}
+
+	role, err := s.authorizer.ClassRole(ctx, user.ID, classID)
+	if err != nil {
+		return entry, err
+	}
+	switch {
+	case role.IsStaff():
+		row.Errors = append(row.Errors, "user is on the staff of this class")
+		return entry, nil
+	case role == models.ClassRoleStudent:
+		row.Action = models.RosterSkip
+		return entry, nil
+	}
+
+	entry.enrollment, err = s.enrollmentRepo.Get(ctx, classID, user.ID)
+	switch {
+	case err == nil:
+		row.Action = models.RosterActivate
+	case errors.Is(err, repository.ErrNotFound):
+		row.Action = models.RosterEnroll
+	default:
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// checkCapacity refuses an import that would seat more students than the class allows.
+func (s *rosterService) checkCapacity(
+	ctx context.Context, class *models.Class, result *models.RosterImportResult,
+) error {
+	if class.Enrollment.Capacity == nil {
+		return nil
+	}
+
+	active, err := s.enrollmentRepo.CountActive(ctx, class.ID)
+	if err != nil {
+		return err
+	}
+	if active+result.Admitted() > *class.Enrollment.Capacity {
+		return ErrRosterExceedsCapacity
+	}
+
+	return nil
+}
+
+// apply writes one planned row and returns the invite token for a new account.
+// The plan was made outside the transaction, so a row that changed since
+// fails the whole import with ErrRosterChanged.
+func (s *rosterService) apply(
+	ctx context.Context, classID uuid.UUID, entry rosterEntry, now time.Time,
+) (string, error) {
+	row := entry.row
+
+	var token string
+	switch row.Action {
+	case models.RosterCreate:
+		var err error
+		if entry.user, token, err = s.createStudent(ctx, row, now); err != nil {
+			return "", err
+		}
+	default:
+		if row.StudentNumber != "" {
+			if err := s.userRepo.FillStudentNumber(ctx, entry.user.ID, row.StudentNumber); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if row.Action == models.RosterActivate {
+		enrollment, err := s.enrollmentRepo.UpdateStatus(
+			ctx, classID, entry.user.ID, entry.enrollment.Status, models.EnrollmentActive, now,
+		)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return "", ErrRosterChanged
+			}
+			return "", err
+		}
+		return token, s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditEnrollmentApproved,
+			EntityType: models.EntityEnrollment,
+			EntityID:   enrollment.ID,
+			Before:     entry.enrollment,
+			After:      enrollment,
+		})
+	}
+
+	enrollment := &models.Enrollment{
+		ID:         uuid.New(),
+		ClassID:    classID,
+		StudentID:  entry.user.ID,
+		Status:     models.EnrollmentActive,
+		EnrolledAt: now,
+	}
+	if err := s.enrollmentRepo.Create(ctx, enrollment); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return "", ErrRosterChanged
+		}
+		return "", fmt.Errorf("failed to create enrollment: %w", err)
+	}
+
+	return token, s.audit.Record(ctx, AuditEntry{
+		Action:     models.AuditEnrollmentCreated,
+		EntityType: models.EntityEnrollment,
+		EntityID:   enrollment.ID,
+		After:      enrollment,
+	})
+}
+
+// createStudent creates a passwordless student account and a set-password
+// token for it. Nobody can sign in to the account until the token is redeemed.
+func (s *rosterService) createStudent(
+	ctx context.Context, row *models.RosterRow, now time.Time,
+) (*models.User, string, error) {
+	user := &models.User{
+		ID:        uuid.New(),
+		Email:     row.Email,
+		Name:      row.Name,
+		Role:      models.RoleStudent,
+		CreatedAt: now,
+	}
+	if row.StudentNumber != "" {
+		user.StudentNumber = &row.StudentNumber
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, "", ErrRosterChanged
+		}
+		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	err = s.passwordResetRepo.Create(ctx, &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(s.config.InviteTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.audit.Record(ctx, AuditEntry{
+		Action:     models.AuditUserInvited,
+		EntityType: models.EntityUser,
+		EntityID:   user.ID,
+		After:      user.ToResponse(),
+	}); err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
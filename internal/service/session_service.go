@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/qrcode"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+)
+
+type SessionService interface {
+	OpenSession(ctx context.Context, teacherID, classID uuid.UUID, input *models.OpenSessionInput) (*models.ClassSession, error)
+	CloseSession(ctx context.Context, teacherID, classID, sessionID uuid.UUID) (*models.ClassSession, error)
+	GetCurrentSession(ctx context.Context, teacherID, classID uuid.UUID) (*models.ClassSession, error)
+	ListSessions(ctx context.Context, teacherID, classID uuid.UUID) ([]models.ClassSession, error)
+	GetCheckinCode(ctx context.Context, teacherID, classID uuid.UUID) (*models.CheckinCodeResponse, error)
+	ExpireSessions(ctx context.Context) error
+	PublishCheckinCodes(ctx context.Context) error
+}
+
+// SessionConfig holds the tunables for attendance sessions.
+type SessionConfig struct {
+	// DefaultDuration applies when the teacher does not pick a duration.
+	DefaultDuration time.Duration
+	// LateThreshold is how long after opening marks still count as present.
+	LateThreshold time.Duration
+	// CheckinURL is the deep-link base encoded into session QR codes.
+	CheckinURL string
+}
+
+type sessionService struct {
+	sessionRepo    repository.SessionRepository
+	classRepo      repository.ClassRepository
+	attendanceRepo repository.AttendanceRepository
+	authorizer     Authorizer
+	transactor     repository.Transactor
+	audit          AuditService
+	broadcaster    Broadcaster
+	checkinCodes   CheckinCodes
+	config         SessionConfig
+}
+
+func NewSessionService(
+	sessionRepo repository.SessionRepository,
+	classRepo repository.ClassRepository,
+	attendanceRepo repository.AttendanceRepository,
+	authorizer Authorizer,
+	transactor repository.Transactor,
+	audit AuditService,
+	broadcaster Broadcaster,
+	checkinCodes CheckinCodes,
+	config SessionConfig,
+) SessionService {
+	return &sessionService{
+		sessionRepo:    sessionRepo,
+		classRepo:      classRepo,
+		attendanceRepo: attendanceRepo,
+		authorizer:     authorizer,
+		transactor:     transactor,
+		audit:          audit,
+		broadcaster:    broadcaster,
+		checkinCodes:   checkinCodes,
+		config:         config,
+	}
+}
+
+// OpenSession starts a new attendance window for a class (requires session:manage).
+func (s *sessionService) OpenSession(
+	ctx context.Context, teacherID, classID uuid.UUID, input *models.OpenSessionInput,
+) (*models.ClassSession, error) {
+	class, err := s.authorizer.AuthorizeClass(ctx, teacherID, classID, models.PermSessionManage)
+	if err != nil {
+		return nil, err
+	}
+	if class.IsArchived() {
+		return nil, ErrClassArchived
+	}
+
+	duration := s.config.DefaultDuration
+	if input.DurationMinutes > 0 {
+		duration = time.Duration(input.DurationMinutes) * time.Minute
+	}
+
+	lateThreshold := s.config.LateThreshold
+	if input.LateAfterMinutes != nil {
+		lateThreshold = time.Duration(*input.LateAfterMinutes) * time.Minute
+	}
+
+	secret, err := generateSecret(sessionSecretSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session secret: %w", err)
+	}
+
+	now := time.Now()
+	lateAt := now.Add(lateThreshold)
+	session := &models.ClassSession{
+		ID:        uuid.New(),
+		ClassID:   classID,
+		OpenedBy:  &teacherID,
+		OpenedAt:  now,
+		ExpiresAt: now.Add(duration),
+		LateAt:    &lateAt,
+		Secret:    secret,
+	}
+
+	var expired []models.ClassSession
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// ArchiveClass holds this lock while it checks for open sessions.
+		locked, err := s.classRepo.GetByIDForUpdate(ctx, classID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		if locked.IsArchived() {
+			return ErrClassArchived
+		}
+
+		// An expired session still counts as open in the database until it
+		// is swept. Only this class's is closed here; the sweeper does the rest.
+		expired, err = s.sessionRepo.CloseExpiredByClassID(ctx, classID, now)
+		if err != nil {
+			return fmt.Errorf("failed to expire sessions: %w", err)
+		}
+		if err := s.finishExpired(ctx, expired); err != nil {
+			return err
+		}
+
+		if err := s.sessionRepo.Create(ctx, session); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrSessionAlreadyOpen
+			}
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditSessionOpened,
+			EntityType: models.EntitySession,
+			EntityID:   session.ID,
+			After:      session.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range expired {
+		s.broadcaster.Broadcast(classID, "", EventSessionClosed, expired[i].ToResponse())
+	}
+	s.broadcaster.Broadcast(classID, "", EventSessionOpened, session.ToResponse())
+
+	return session, nil
+}
+
+// CloseSession ends an open session before it expires (requires session:manage)
+// and records every enrolled student who did not mark as absent.
+func (s *sessionService) CloseSession(
+	ctx context.Context, teacherID, classID, sessionID uuid.UUID,
+) (*models.ClassSession, error) {
+	if err := s.authorize(ctx, teacherID, classID, models.PermSessionManage); err != nil {
+		return nil, err
+	}
+
+	session, err := s.getClassSession(ctx, classID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !session.IsOpen(now) {
+		return nil, ErrSessionClosed
+	}
+
+	before := session.ToResponse()
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Close(ctx, sessionID, now); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrSessionClosed
+			}
+			return fmt.Errorf("failed to close session: %w", err)
+		}
+		session.ClosedAt = &now
+
+		if err := s.recordAbsentees(ctx, sessionID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditSessionClosed,
+			EntityType: models.EntitySession,
+			EntityID:   sessionID,
+			Before:     before,
+			After:      session.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.broadcaster.Broadcast(classID, "", EventSessionClosed, session.ToResponse())
+
+	return session, nil
+}
+
+// GetCurrentSession returns the open session for a class (requires session:manage).
+func (s *sessionService) GetCurrentSession(
+	ctx context.Context, teacherID, classID uuid.UUID,
+) (*models.ClassSession, error) {
+	if err := s.authorize(ctx, teacherID, classID, models.PermSessionManage); err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.GetOpenByClassID(ctx, classID, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNoOpenSession
+		}
+		return nil, fmt.Errorf("failed to get open session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions returns every session of a class, newest first (requires attendance:view).
+func (s *sessionService) ListSessions(
+	ctx context.Context, teacherID, classID uuid.UUID,
+) ([]models.ClassSession, error) {
+	if err := s.authorize(ctx, teacherID, classID, models.PermAttendanceView); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.GetByClassID(ctx, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// GetCheckinCode returns the current rotating code for the open session (requires session:manage).
+func (s *sessionService) GetCheckinCode(
+	ctx context.Context, teacherID, classID uuid.UUID,
+) (*models.CheckinCodeResponse, error) {
+	session, err := s.GetCurrentSession(ctx, teacherID, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.checkinCode(session, time.Now()), nil
+}
+
+// PublishCheckinCodes pushes the current code and QR of every open session
+// to the teachers watching it. Called once per rotation period; classes
+// nobody is watching are skipped.
+func (s *sessionService) PublishCheckinCodes(ctx context.Context) error {
+	classIDs := s.broadcaster.Watching(models.RoleTeacher)
+	if len(classIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	sessions, err := s.sessionRepo.GetOpenByClassIDs(ctx, classIDs, now)
+	if err != nil {
+		return fmt.Errorf("failed to get open sessions: %w", err)
+	}
+
+	for i := range sessions {
+		code := s.checkinCode(&sessions[i], now)
+
+		svg, err := qrcode.SVG(code.Link)
+		if err != nil {
+			return fmt.Errorf("failed to render check-in qr: %w", err)
+		}
+
+		s.broadcaster.Broadcast(sessions[i].ClassID, models.RoleTeacher, EventCheckinRotated, models.CheckinCodeEvent{
+			CheckinCodeResponse: *code,
+			QRSVG:               svg,
+		})
+	}
+
+	return nil
+}
+
+func (s *sessionService) checkinCode(session *models.ClassSession, now time.Time) *models.CheckinCodeResponse {
+	code, expiresAt := s.checkinCodes.Generate(session.Secret, now)
+
+	return &models.CheckinCodeResponse{
+		SessionID:     session.ID,
+		Code:          code,
+		ExpiresAt:     expiresAt,
+		PeriodSeconds: int(s.checkinCodes.Period / time.Second),
+		Link:          s.checkinLink(session, code),
+	}
+}
+
+// checkinLink builds the deep link a student's phone opens after scanning the QR code.
+func (s *sessionService) checkinLink(session *models.ClassSession, code string) string {
+	query := url.Values{}
+	query.Set("class_id", session.ClassID.String())
+	query.Set("session_id", session.ID.String())
+	query.Set("code", code)
+	return s.config.CheckinURL + "?" + query.Encode()
+}
+
+// ExpireSessions closes every session past its expiry, records absentees and announces it.
+func (s *sessionService) ExpireSessions(ctx context.Context) error {
+	var expired []models.ClassSession
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		expired, err = s.sessionRepo.CloseExpired(ctx, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to expire sessions: %w", err)
+		}
+		return s.finishExpired(ctx, expired)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		s.broadcaster.Broadcast(expired[i].ClassID, "", EventSessionClosed, expired[i].ToResponse())
+	}
+
+	return nil
+}
+
+// finishExpired records absentees and audit events for sessions just closed on expiry.
+func (s *sessionService) finishExpired(ctx context.Context, expired []models.ClassSession) error {
+	if len(expired) == 0 {
+		return nil
+	}
+
+	sessionIDs := make([]uuid.UUID, len(expired))
+	for i := range expired {
+		sessionIDs[i] = expired[i].ID
+	}
+	if err := s.recordAbsentees(ctx, sessionIDs...); err != nil {
+		return err
+	}
+
+	for i := range expired {
+		if err := s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditSessionExpired,
+			EntityType: models.EntitySession,
+			EntityID:   expired[i].ID,
+			After:      expired[i].ToResponse(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAbsentees creates absent records for enrolled students with no record in the closed sessions.
+func (s *sessionService) recordAbsentees(ctx context.Context, sessionIDs ...uuid.UUID) error {
+	if _, err := s.attendanceRepo.CreateAbsent(ctx, sessionIDs); err != nil {
+		return fmt.Errorf("failed to record absentees: %w", err)
+	}
+
+	return nil
+}
+
+// authorize checks the user holds perm in the class.
+func (s *sessionService) authorize(
+	ctx context.Context, userID, classID uuid.UUID, perm models.Permission,
+) error {
+	_, err := s.authorizer.AuthorizeClass(ctx, userID, classID, perm)
+	return err
+}
+
+// getClassSession loads a session and ensures it belongs to the class in the URL.
+func (s *sessionService) getClassSession(
+	ctx context.Context, classID, sessionID uuid.UUID,
+) (*models.ClassSession, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.ClassID != classID {
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
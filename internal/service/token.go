@@ -0,0 +1,30 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// opaqueTokenSize is the number of random bytes in refresh and emailed tokens.
+const opaqueTokenSize = 32
+
+// generateOpaqueToken returns a random URL-safe token and the hash to store for it.
+func generateOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the hex SHA-256 of a token. The tokens carry enough
+// entropy that a fast unsalted hash is sufficient, and it allows lookup by hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"crypto/hmac"
+	"encoding/base32"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod and totpDigits are the RFC 6238 defaults every authenticator app supports.
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is how many steps either side of now are accepted, for clock drift.
+	totpSkew = 1
+	// totpSecretSize is the TOTP key length (RFC 4226 recommends 160 bits).
+	totpSecretSize = 20
+
+	// recoveryCodeCount is how many single-use recovery codes are issued on enrollment.
+	recoveryCodeCount = 10
+	// recoveryCodeSize is the random bytes per recovery code, giving 16 base32 characters.
+	recoveryCodeSize = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// verifyTOTP returns the time step code belongs to, or false if it matches
+// no step within the skew. Steps at or before lastUsedStep are refused so
+// an observed code cannot be replayed.
+func verifyTOTP(secret []byte, code string, lastUsedStep int64, now time.Time) (int64, bool) {
+	current := now.Unix() / int64(totpPeriod/time.Second)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected := hotp(secret, uint64(step), totpDigits)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI builds the otpauth:// URI authenticator apps read from a QR code.
+func totpURI(issuer, account string, secret []byte) string {
+	query := url.Values{}
+	query.Set("secret", totpEncoding.EncodeToString(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(int(totpPeriod/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// generateRecoveryCodes returns codes formatted for reading, XXXX-XXXX-XXXX-XXXX.
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b, err := generateSecret(recoveryCodeSize)
+		if err != nil {
+			return nil, err
+		}
+		raw := totpEncoding.EncodeToString(b)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode lets users type codes without dashes or in lower case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 16 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyTOTP(t *testing.T) {
+	// Step 3 is current; rfc4226Secret's codes for steps 1 to 4 are
+	// 287082, 359152, 969429 and 338314.
+	now := time.Unix(95, 0)
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		wantStep     int64
+		wantOK       bool
+	}{
+		{"current step", "969429", 0, 3, true},
+		{"previous step within skew", "359152", 0, 2, true},
+		{"next step within skew", "338314", 0, 4, true},
+		{"step outside the skew", "287082", 0, 0, false},
+		{"replayed step", "969429", 3, 0, false},
+		{"step before the last used", "359152", 3, 0, false},
+		{"step after the last used", "338314", 3, 4, true},
+		{"wrong code", "123456", 0, 0, false},
+		{"truncated code", "96942", 0, 0, false},
+		{"empty code", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTP(rfc4226Secret, tt.code, tt.lastUsedStep, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("verifyTOTP(%q) = (%d, %v), want (%d, %v)", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"formatted", "ABCD-EFGH-IJKL-MNOP", "ABCD-EFGH-IJKL-MNOP"},
+		{"lower case", "abcd-efgh-ijkl-mnop", "ABCD-EFGH-IJKL-MNOP"},
+		{"without dashes", "abcdefghijklmnop", "ABCD-EFGH-IJKL-MNOP"},
+		{"with spaces", "abcd efgh ijkl mnop", "ABCD-EFGH-IJKL-MNOP"},
+		{"wrong length", "abc-def", "ABCDEF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeRecoveryCode(tt.code); got != tt.want {
+				t.Errorf("normalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if normalizeRecoveryCode(strings.ToLower(code)) != code {
+			t.Errorf("code %q does not survive normalization", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahiriqbal095/attendify/internal/models"
+	"github.com/tahiriqbal095/attendify/internal/repository"
+	"github.com/tahiriqbal095/attendify/internal/requestctx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+// UserService is account management for admins. Methods taking an adminID
+// refuse to act on the admin's own account, so an admin cannot lock
+// themselves out.
+type UserService interface {
+	ListUsers(ctx context.Context, filter *models.UserFilter) (*models.UserPage, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	// ChangeRole takes effect immediately: the user's sessions are revoked
+	// because their tokens carry the old role. It returns
+	// ErrUserTeachesClasses rather than leave a user who can no longer
+	// create classes as an owner or co-teacher.
+	ChangeRole(ctx context.Context, adminID, userID uuid.UUID, role models.Role) (*models.User, error)
+	Disable(ctx context.Context, adminID, userID uuid.UUID) (*models.User, error)
+	Enable(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	// ForcePasswordReset signs the user out and blocks sign-in until they
+	// choose a new password through the emailed reset link.
+	ForcePasswordReset(ctx context.Context, userID uuid.UUID) error
+	// Delete removes an account for good. It returns ErrUserOwnsClasses
+	// rather than take the user's classes and their attendance with it;
+	// Disable such accounts instead. Sessions the user opened in other
+	// classes are kept with no opener.
+	Delete(ctx context.Context, adminID, userID uuid.UUID) error
+	// SeedAdmin creates the first admin account. It does nothing if an
+	// active admin already exists and reports whether it created one.
+	SeedAdmin(ctx context.Context, email, name, password string) (bool, error)
+}
+
+type userService struct {
+	userRepo    repository.UserRepository
+	classRepo   repository.ClassRepository
+	staffRepo   repository.ClassStaffRepository
+	authService AuthService
+	transactor  repository.Transactor
+	audit       AuditService
+}
+
+func NewUserService(
+	userRepo repository.UserRepository,
+	classRepo repository.ClassRepository,
+	staffRepo repository.ClassStaffRepository,
+	authService AuthService,
+	transactor repository.Transactor,
+	audit AuditService,
+) UserService {
+	return &userService{
+		userRepo:    userRepo,
+		classRepo:   classRepo,
+		staffRepo:   staffRepo,
+		authService: authService,
+		transactor:  transactor,
+		audit:       audit,
+	}
+}
+
+func (s *userService) ListUsers(ctx context.Context, filter *models.UserFilter) (*models.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	users, err := s.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	page := &models.UserPage{
+		Users:  make([]models.UserResponse, len(users)),
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	for i := range users {
+		page.Users[i] = users[i].ToResponse()
+	}
+	if len(users) == filter.Limit {
+		next := filter.Offset + filter.Limit
+		page.NextOffset = &next
+	}
+
+	return page, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *userService) ChangeRole(
+	ctx context.Context, adminID, userID uuid.UUID, role models.Role,
+) (*models.User, error) {
+	if adminID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	return s.update(ctx, userID, models.AuditUserRoleChanged, func(ctx context.Context, user *models.User) error {
+		if user.Role == role {
+			return nil
+		}
+		// Owners and co-teachers act as teachers in their classes whatever
+		// their account role, so they must keep one that can teach.
+		if !role.Can(models.PermClassCreate) {
+			teaches, err := s.staffRepo.HasTeachingRole(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if teaches {
+				return ErrUserTeachesClasses
+			}
+		}
+		if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+			return err
+		}
+		user.Role = role
+		return s.authService.RevokeSessions(ctx, userID)
+	})
+}
+
+func (s *userService) Disable(ctx context.Context, adminID, userID uuid.UUID) (*models.User, error) {
+	if adminID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	return s.update(ctx, userID, models.AuditUserDisabled, func(ctx context.Context, user *models.User) error {
+		if user.IsDisabled() {
+			return nil
+		}
+		now := time.Now()
+		if err := s.userRepo.SetDisabled(ctx, userID, &now); err != nil {
+			return err
+		}
+		user.DisabledAt = &now
+		return s.authService.RevokeSessions(ctx, userID)
+	})
+}
+
+func (s *userService) Enable(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	return s.update(ctx, userID, models.AuditUserEnabled, func(ctx context.Context, user *models.User) error {
+		if !user.IsDisabled() {
+			return nil
+		}
+		if err := s.userRepo.SetDisabled(ctx, userID, nil); err != nil {
+			return err
+		}
+		user.DisabledAt = nil
+		return nil
+	})
+}
+
+func (s *userService) ForcePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.update(ctx, userID, models.AuditPasswordResetForced,
+		func(ctx context.Context, user *models.User) error {
+			if err := s.userRepo.SetPasswordResetRequired(ctx, userID, true); err != nil {
+				return err
+			}
+			user.PasswordResetRequired = true
+			return s.authService.RevokeSessions(ctx, userID)
+		})
+	if err != nil {
+		return err
+	}
+
+	return s.authService.RequestPasswordReset(ctx, user.Email)
+}
+
+func (s *userService) Delete(ctx context.Context, adminID, userID uuid.UUID) error {
+	if adminID == userID {
+		return ErrCannotModifySelf
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.GetUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		// Deleting the owner would cascade to the classes.
+		owns, err := s.classRepo.ExistsByTeacherID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if owns {
+			return ErrUserOwnsClasses
+		}
+
+		// The revocation cutoff outlives the user, so access tokens die with the account.
+		if err := s.authService.RevokeSessions(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditUserDeleted,
+			EntityType: models.EntityUser,
+			EntityID:   userID,
+			Before:     user.ToResponse(),
+		})
+	})
+}
+
+func (s *userService) SeedAdmin(ctx context.Context, email, name, password string) (bool, error) {
+	exists, err := s.userRepo.ExistsWithRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:              uuid.New(),
+		Email:           models.NormalizeEmail(email),
+		PasswordHash:    string(hashedPassword),
+		Name:            name,
+		Role:            models.RoleAdmin,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Never promote an existing account: whoever registered the
+		// address first would become admin.
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrEmailTaken
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		// There is no request; the new admin is its own actor.
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     models.AuditUserRegistered,
+			EntityType: models.EntityUser,
+			EntityID:   user.ID,
+			After:      user.ToResponse(),
+			Actor:      &requestctx.Actor{UserID: user.ID, Role: user.Role},
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// update loads a user, applies change and audits the before and after
+// states, all in one transaction.
+func (s *userService) update(
+	ctx context.Context, userID uuid.UUID, action string,
+	change func(ctx context.Context, user *models.User) error,
+) (*models.User, error) {
+	var user *models.User
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.GetUser(ctx, userID); err != nil {
+			return err
+		}
+
+		before := user.ToResponse()
+		if err := change(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEntry{
+			Action:     action,
+			EntityType: models.EntityUser,
+			EntityID:   userID,
+			Before:     before,
+			After:      user.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,195 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+const (
+	// writeWait is the time allowed to write a message to the peer.
+	writeWait = 10 * time.Second
+
+	// pongWait is the time allowed to read the next pong from the peer.
+	pongWait = 60 * time.Second
+
+	// pingPeriod must be shorter than pongWait so pings arrive before the deadline.
+	pingPeriod = (pongWait * 9) / 10
+
+	// maxMessageSize caps inbound frames; clients only need to send control frames.
+	maxMessageSize = 512
+
+	// sendBufferSize is the per-client queue length before it counts as slow.
+	sendBufferSize = 32
+
+	// recheckPeriod is how often a connected subscriber's access is checked again.
+	recheckPeriod = 30 * time.Second
+
+	// recheckTimeout bounds one access check.
+	recheckTimeout = 5 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Authentication uses a bearer token rather than cookies,
+	// so cross-origin upgrades carry no ambient credentials.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Subscriber identifies an authenticated user joining a class room.
+type Subscriber struct {
+	ClassID uuid.UUID
+	UserID  uuid.UUID
+	Role    models.Role
+	// ExpiresAt is when the token the subscriber connected with expires;
+	// the connection is closed then.
+	ExpiresAt time.Time
+	// Authorize reports whether the subscriber may still follow the room.
+	// It is called every recheckPeriod, and the connection is closed once
+	// it reports false, e.g. after the token is revoked.
+	Authorize func(ctx context.Context) (bool, error)
+}
+
+type Client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	classID   uuid.UUID
+	userID    uuid.UUID
+	role      models.Role
+	expiresAt time.Time
+	authorize func(ctx context.Context) (bool, error)
+	send      chan []byte
+}
+
+// Serve upgrades the request and joins the subscriber to its class room.
+// Authentication and authorization must be done before calling Serve.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, sub Subscriber) error {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &Client{
+		hub:       h,
+		conn:      conn,
+		classID:   sub.ClassID,
+		userID:    sub.UserID,
+		role:      sub.Role,
+		expiresAt: sub.ExpiresAt,
+		authorize: sub.Authorize,
+		send:      make(chan []byte, sendBufferSize),
+	}
+
+	select {
+	case h.register <- client:
+	case <-h.done:
+		conn.Close()
+		return nil
+	}
+
+	go client.writePump()
+	go client.readPump()
+
+	return nil
+}
+
+// readPump discards inbound messages and unregisters the client on disconnect.
+func (c *Client) readPump() {
+	defer func() {
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump drains the send queue and keeps the connection alive with pings.
+// It exits when the hub closes the send queue, or closes the connection
+// itself once the subscriber's access ends.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	recheck := time.NewTicker(recheckPeriod)
+	expiry := time.NewTimer(time.Until(c.expiresAt))
+	defer func() {
+		ticker.Stop()
+		recheck.Stop()
+		expiry.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-expiry.C:
+			c.closeWith("token expired")
+			return
+
+		case <-recheck.C:
+			if !c.stillAuthorized() {
+				c.closeWith("access revoked")
+				return
+			}
+		}
+	}
+}
+
+// stillAuthorized asks the subscriber's Authorize callback. A failed check
+// keeps the connection; it is retried next period.
+func (c *Client) stillAuthorized() bool {
+	if c.authorize == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recheckTimeout)
+	defer cancel()
+
+	ok, err := c.authorize(ctx)
+	if err != nil {
+		c.hub.logger.Warn().Err(err).
+			Str("class_id", c.classID.String()).
+			Str("user_id", c.userID.String()).
+			Msg("failed to recheck websocket client access")
+		return true
+	}
+	return ok
+}
+
+// closeWith tells the peer why the server is ending the connection, so it
+// can reconnect with a fresh token.
+func (c *Client) closeWith(reason string) {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason))
+}
@@ -0,0 +1,172 @@
+// Package ws implements the real-time WebSocket layer.
+// A single Hub goroutine owns every class room, so client bookkeeping
+// never needs locks.
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/tahiriqbal095/attendify/internal/models"
+)
+
+// broadcastBufferSize bounds how many pending broadcasts the hub queues
+// before new ones are dropped rather than blocking the caller.
+const broadcastBufferSize = 256
+
+// Event is the envelope written to every client.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// message is a pre-encoded event addressed to one class room.
+type message struct {
+	classID uuid.UUID
+	role    models.Role
+	payload []byte
+}
+
+// watchingQuery asks Run which class rooms have a client with role.
+type watchingQuery struct {
+	role  models.Role
+	reply chan []uuid.UUID
+}
+
+type Hub struct {
+	rooms      map[uuid.UUID]map[*Client]struct{}
+	register   chan *Client
+	unregister chan *Client
+	broadcast  chan message
+	watching   chan watchingQuery
+	done       chan struct{}
+	logger     zerolog.Logger
+
+	// dropped counts broadcasts discarded because the queue was full.
+	dropped atomic.Uint64
+}
+
+func NewHub(logger zerolog.Logger) *Hub {
+	return &Hub{
+		rooms:      make(map[uuid.UUID]map[*Client]struct{}),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		broadcast:  make(chan message, broadcastBufferSize),
+		watching:   make(chan watchingQuery),
+		done:       make(chan struct{}),
+		logger:     logger,
+	}
+}
+
+// Run owns the room map until ctx is cancelled, then disconnects every client.
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	for {
+		select {
+		case client := <-h.register:
+			room, ok := h.rooms[client.classID]
+			if !ok {
+				room = make(map[*Client]struct{})
+				h.rooms[client.classID] = room
+			}
+			room[client] = struct{}{}
+
+		case client := <-h.unregister:
+			h.remove(client)
+
+		case msg := <-h.broadcast:
+			for client := range h.rooms[msg.classID] {
+				if msg.role != "" && client.role != msg.role {
+					continue
+				}
+				select {
+				case client.send <- msg.payload:
+				default:
+					// Slow consumer: drop it instead of stalling the whole hub.
+					h.logger.Warn().
+						Str("class_id", msg.classID.String()).
+						Str("user_id", client.userID.String()).
+						Msg("dropping slow websocket client")
+					h.remove(client)
+				}
+			}
+
+		case query := <-h.watching:
+			var classIDs []uuid.UUID
+			for classID, room := range h.rooms {
+				for client := range room {
+					if query.role == "" || client.role == query.role {
+						classIDs = append(classIDs, classID)
+						break
+					}
+				}
+			}
+			query.reply <- classIDs
+
+		case <-ctx.Done():
+			for _, room := range h.rooms {
+				for client := range room {
+					close(client.send)
+				}
+			}
+			h.rooms = nil
+			return
+		}
+	}
+}
+
+// Broadcast sends an event to every client in a class room.
+// When role is non-empty only clients with that role receive it.
+// It never blocks: if the hub is backed up the event is dropped.
+func (h *Hub) Broadcast(classID uuid.UUID, role models.Role, eventType string, data interface{}) {
+	payload, err := json.Marshal(Event{Type: eventType, Data: data})
+	if err != nil {
+		h.logger.Error().Err(err).Str("type", eventType).Msg("failed to encode websocket event")
+		return
+	}
+
+	select {
+	case h.broadcast <- message{classID: classID, role: role, payload: payload}:
+	case <-h.done:
+	default:
+		h.logger.Warn().
+			Str("class_id", classID.String()).
+			Str("type", eventType).
+			Uint64("dropped_total", h.dropped.Add(1)).
+			Msg("websocket broadcast queue full, event dropped")
+	}
+}
+
+// Watching returns the classes with at least one connected client of role,
+// or of any role when role is empty.
+func (h *Hub) Watching(role models.Role) []uuid.UUID {
+	reply := make(chan []uuid.UUID, 1)
+	select {
+	case h.watching <- watchingQuery{role: role, reply: reply}:
+		return <-reply
+	case <-h.done:
+		return nil
+	}
+}
+
+// remove deletes a client from its room and closes its send queue.
+// Must only be called from Run.
+func (h *Hub) remove(client *Client) {
+	room, ok := h.rooms[client.classID]
+	if !ok {
+		return
+	}
+	if _, ok := room[client]; !ok {
+		return
+	}
+
+	delete(room, client)
+	close(client.send)
+	if len(room) == 0 {
+		delete(h.rooms, client.classID)
+	}
+}